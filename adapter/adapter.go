@@ -1,6 +1,12 @@
 package adapter
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrNotConnected = errors.New("adapter is not connected")
 
 type PlaceholderStyle string
 
@@ -19,14 +25,40 @@ type AdapterInfo interface {
 	Placeholder() PlaceholderStyle
 	SpatialType() SpatialExtension
 	DatabaseType() DbType
+	Dialect() Dialect
+}
+
+// Rows is a result set of a query. It is satisfied by *sql.Rows and is compatible with dbscan.Rows.
+type Rows interface {
+	Close() error
+	Err() error
+	Next() bool
+	Columns() ([]string, error)
+	Scan(dest ...interface{}) error
+}
+
+// Tx is a transaction opened by an adapter, which executes statements like the adapter itself
+type Tx interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (Rows, error)
+	Commit() error
+	Rollback() error
 }
 
 type Adapter interface {
 	Connect(dsn string) error
+	ConnectWithConfig(config Config) error
 	Disconnect() error
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (Rows, error)
+	Begin(ctx context.Context) (Tx, error)
 	Placeholder() PlaceholderStyle
 	SpatialType() SpatialExtension
 	DatabaseType() DbType
+	Dialect() Dialect
+}
+
+// StatsProvider is implemented by adapters backed by a connection pool
+type StatsProvider interface {
+	Stats() sql.DBStats
 }
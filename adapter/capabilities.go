@@ -0,0 +1,11 @@
+package adapter
+
+import "context"
+
+// Optional capabilities of an adapter. Callers should check for them with a type assertion
+// and fall back to plain statements when an adapter does not implement them.
+
+// CopyFromer is implemented by adapters that can bulk load rows with the COPY protocol
+type CopyFromer interface {
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error)
+}
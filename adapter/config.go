@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Config holds the connection details and pool settings of a database.
+// Zero values are left to the defaults of the driver and database/sql.
+type Config struct {
+	Host            string // Defaults to localhost
+	Port            int    // Defaults to 5432
+	User            string
+	Password        string
+	Database        string
+	SSLMode         string // e.g. disable, require, verify-full
+	SearchPath      string
+	ApplicationName string
+
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration // Server side timeout of every statement, sent as a runtime parameter
+
+	// StatementCacheSize is the number of prepared statements cached by the adapter.
+	// The database/sql adapter only caches statements if it is positive.
+	// The pgx adapter keeps the default cache of pgx if it is zero, and a negative size disables that cache.
+	StatementCacheSize int
+}
+
+// DSN returns the connection URL for the config
+func (c Config) DSN() string {
+	host := c.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := c.Port
+	if port == 0 {
+		port = 5432
+	}
+	dsn := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:   "/" + c.Database,
+	}
+	if c.User != "" {
+		if c.Password != "" {
+			dsn.User = url.UserPassword(c.User, c.Password)
+		} else {
+			dsn.User = url.User(c.User)
+		}
+	}
+	params := url.Values{}
+	if c.SSLMode != "" {
+		params.Set("sslmode", c.SSLMode)
+	}
+	if c.SearchPath != "" {
+		params.Set("search_path", c.SearchPath)
+	}
+	if c.ApplicationName != "" {
+		params.Set("application_name", c.ApplicationName)
+	}
+	if c.StatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+	dsn.RawQuery = params.Encode()
+	return dsn.String()
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+var ErrUnsupported = errors.New("unsupported by dialect")
+
+type SpatialPredicate string
+
+const (
+	SpatialEquals  SpatialPredicate = "SPATIAL_EQUALS"
+	SpatialCovers  SpatialPredicate = "SPATIAL_COVERS"
+	SpatialDWithin SpatialPredicate = "SPATIAL_DWITHIN"
+)
+
+type JSONPredicate string
+
+const (
+	JSONContains JSONPredicate = "JSON_CONTAINS" // The column contains the JSON document, e.g. {"fuel_type": "ev"}
+	JSONHasKey   JSONPredicate = "JSON_HAS_KEY"  // The column has the key at its top level
+)
+
+// SpatialArg is an operand of a spatial expression.
+// Expr is either a column reference or a bound parameter placeholder (IsParam).
+type SpatialArg struct {
+	Expr    string
+	IsParam bool
+}
+
+func ColumnArg(expr string) SpatialArg {
+	return SpatialArg{Expr: expr}
+}
+
+func ParamArg(placeholder string) SpatialArg {
+	return SpatialArg{Expr: placeholder, IsParam: true}
+}
+
+// TypeChange describes how the type of an existing column relates to the type a model wants for it
+type TypeChange int
+
+const (
+	TypeUnchanged TypeChange = iota
+	TypeWidened              // The wanted type holds every value of the existing type, e.g. a longer varchar
+	TypeChanged              // Values of the existing type may not convert to the wanted type
+)
+
+// Index describes a non spatial index for Dialect.CreateIndex, with identifiers already quoted
+type Index struct {
+	Name    string
+	Table   string
+	Unique  bool
+	Method  string   // Index method, the database default if empty
+	Keys    []string // Indexed columns or parenthesized expressions, optionally followed by ASC or DESC
+	Include []string // Columns stored in the index without being indexed
+	Where   string   // Condition of a partial index
+}
+
+// Dialect renders the backend specific parts of generated SQL.
+// Features a backend does not support are reported with an error wrapping ErrUnsupported.
+type Dialect interface {
+	DatabaseType() DbType
+	SpatialType() SpatialExtension
+
+	// QuoteIdentifier quotes a table, column or index name so that reserved words and case are preserved
+	QuoteIdentifier(identifier string) string
+	// BindVar returns the placeholder for the n-th (1-based) bound parameter
+	BindVar(n int) string
+	// MaxParams returns the maximum number of bound parameters in a single statement
+	MaxParams() int
+
+	// ColumnType returns the column type used for the field in table creation
+	ColumnType(field *model.Field) (string, error)
+	// ColumnDefault returns the literal used in a DEFAULT qualifier for the value
+	ColumnDefault(value interface{}) (string, error)
+	// SpatialIndex returns the statement creating a spatial index on the column
+	SpatialIndex(indexName string, table string, column string, ifNotExists bool) (string, error)
+	// CreateIndex returns the statement creating a non spatial index
+	CreateIndex(index Index, ifNotExists bool) (string, error)
+	// DropIndex returns the statement dropping an index of the table
+	DropIndex(indexName string, table string, ifExists bool) (string, error)
+	// RenameIndex returns the statement renaming an index of the table, if it exists
+	RenameIndex(indexName string, table string, newIndexName string) (string, error)
+	// DropTable returns the statement dropping the table, along with dependent objects if cascade is set
+	DropTable(table string, ifExists bool, cascade bool) (string, error)
+	// TruncateTable returns the statement removing all rows of the table, along with rows referencing them if cascade is set
+	TruncateTable(table string, cascade bool) (string, error)
+	// RenameTable returns the statement renaming the table
+	RenameTable(table string, newTable string) (string, error)
+	// AlterColumnType returns the statement changing the type of an existing column
+	AlterColumnType(table string, column string, columnType string) (string, error)
+	// AdvisoryLock returns the statement taking a lock on the key bound as placeholder, held until the end of the transaction
+	AdvisoryLock(placeholder string) (string, error)
+	// CreateMigrationsTable returns the statement creating the bookkeeping table of migrations, if it does not exist,
+	// with version, name and applied_at columns
+	CreateMigrationsTable(table string) (string, error)
+
+	// ColumnsQuery returns a query reading the name, type and nullability of each column of the table bound as the first parameter.
+	// A table that does not exist has no columns.
+	ColumnsQuery() (string, error)
+	// IndexesQuery returns a query reading the name, uniqueness, whether it is the primary key
+	// and the comma separated columns of each index of the table bound as the first parameter
+	IndexesQuery() (string, error)
+	// CompareColumnType compares a column type read through ColumnsQuery with a type returned by ColumnType
+	CompareColumnType(existing string, wanted string) TypeChange
+
+	// EncodeSpatial wraps a bound spatial parameter for insertion into a spatial column
+	EncodeSpatial(placeholder string) (string, error)
+	// DecodeSpatial wraps a spatial column so that it is read back as GeoJSON
+	DecodeSpatial(column string) (string, error)
+
+	// SpatialPredicate renders a boolean spatial predicate over args.
+	// SpatialDWithin takes a third argument holding the distance in meters.
+	SpatialPredicate(predicate SpatialPredicate, args ...SpatialArg) (string, error)
+	// SpatialDistance renders an expression ordering rows by distance between a and b
+	SpatialDistance(a SpatialArg, b SpatialArg) (string, error)
+
+	// JSONPath renders an expression reading the value at path of a json column as text
+	JSONPath(column string, path []string) (string, error)
+	// JSONPredicate renders a boolean predicate of a jsonb column and a bound parameter
+	JSONPredicate(predicate JSONPredicate, column string, placeholder string) (string, error)
+}
+
+func unsupported(dialect DbType, feature string) error {
+	return fmt.Errorf("%w: %s does not support %s", ErrUnsupported, dialect, feature)
+}
@@ -0,0 +1,185 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PgxAdapter talks to Postgres through a native pgx connection pool instead of database/sql.
+// Besides the Adapter contract, it implements CopyFromer and StatsProvider.
+type PgxAdapter struct {
+	pool *pgxpool.Pool
+}
+
+func (p *PgxAdapter) Connect(dsn string) error {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return err
+	}
+	return p.connect(config)
+}
+
+// ConnectWithConfig connects with the pool settings of config.
+// pgxpool has no limit on idle connections, so MaxIdleConns is ignored.
+// Prepared statements are cached by each connection of the pool, StatementCacheSize sets the size of these caches.
+func (p *PgxAdapter) ConnectWithConfig(config Config) error {
+	poolConfig, err := pgxpool.ParseConfig(config.DSN())
+	if err != nil {
+		return err
+	}
+	if config.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(config.MaxOpenConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
+	if config.StatementCacheSize < 0 {
+		poolConfig.ConnConfig.BuildStatementCache = nil
+	} else if config.StatementCacheSize > 0 {
+		size := config.StatementCacheSize
+		poolConfig.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModePrepare, size)
+		}
+	}
+	return p.connect(poolConfig)
+}
+
+// connect opens the pool, which establishes its first connection immediately so that an invalid DSN fails here
+func (p *PgxAdapter) connect(config *pgxpool.Config) error {
+	config.AfterConnect = registerSpatialTypes
+	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	p.pool = pool
+	return nil
+}
+
+func (p *PgxAdapter) Disconnect() error {
+	if p.pool != nil {
+		p.pool.Close()
+	}
+	return nil
+}
+
+func (p *PgxAdapter) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if p.pool == nil {
+		return nil, ErrNotConnected
+	}
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxscan.NewRowsAdapter(rows), nil
+}
+
+func (p *PgxAdapter) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if p.pool == nil {
+		return nil, ErrNotConnected
+	}
+	tag, err := p.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return commandResult(tag), nil
+}
+
+func (p *PgxAdapter) Begin(ctx context.Context) (Tx, error) {
+	if p.pool == nil {
+		return nil, ErrNotConnected
+	}
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxTx{tx: tx, ctx: ctx}, nil
+}
+
+func (p *PgxAdapter) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if p.pool == nil {
+		return 0, ErrNotConnected
+	}
+	return p.pool.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+}
+
+// Stats maps the statistics of the pgx pool onto sql.DBStats
+func (p *PgxAdapter) Stats() sql.DBStats {
+	if p.pool == nil {
+		return sql.DBStats{}
+	}
+	stat := p.pool.Stat()
+	return sql.DBStats{
+		MaxOpenConnections: int(stat.MaxConns()),
+		OpenConnections:    int(stat.TotalConns()),
+		InUse:              int(stat.AcquiredConns()),
+		Idle:               int(stat.IdleConns()),
+		WaitCount:          stat.EmptyAcquireCount(),
+		WaitDuration:       stat.AcquireDuration(),
+	}
+}
+
+func (p PgxAdapter) Placeholder() PlaceholderStyle {
+	return DollarPlaceholder
+}
+
+func (p PgxAdapter) SpatialType() SpatialExtension {
+	return PostGisExtension
+}
+
+func (p PgxAdapter) DatabaseType() DbType {
+	return PostgreSQL
+}
+
+func (p PgxAdapter) Dialect() Dialect {
+	return PostgresDialect{}
+}
+
+type pgxTx struct {
+	tx  pgx.Tx
+	ctx context.Context // Context the transaction was begun with, also used to end it
+}
+
+func (t pgxTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tag, err := t.tx.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return commandResult(tag), nil
+}
+
+func (t pgxTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	rows, err := t.tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxscan.NewRowsAdapter(rows), nil
+}
+
+func (t pgxTx) Commit() error {
+	return t.tx.Commit(t.ctx)
+}
+
+func (t pgxTx) Rollback() error {
+	return t.tx.Rollback(t.ctx)
+}
+
+// commandResult implements sql.Result for the command tag returned by pgx
+type commandResult pgconn.CommandTag
+
+func (r commandResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by Postgres")
+}
+
+func (r commandResult) RowsAffected() (int64, error) {
+	return pgconn.CommandTag(r).RowsAffected(), nil
+}
@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	wkbPoint   = 1
+	wkbPolygon = 3
+	ewkbSRID   = 0x20000000
+	wgs84SRID  = 4326
+)
+
+// Geography is the pgx representation of PostGIS geography and geometry values.
+// It encodes model.Location and model.Region as EWKB, so that they can be sent in the binary format, e.g. with CopyFrom.
+type Geography struct {
+	EWKB   []byte
+	Status pgtype.Status
+}
+
+func (g *Geography) Set(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*g = Geography{Status: pgtype.Null}
+	case model.Location:
+		*g = Geography{EWKB: encodeEWKB(wkbPoint, value.Lon(), value.Lat()), Status: pgtype.Present}
+	case *model.Location:
+		return g.Set(*value)
+	case model.Region:
+		coords := value.Coords()
+		ewkb := encodeEWKB(wkbPolygon)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, 1)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(len(coords)))
+		for _, point := range coords {
+			ewkb = appendFloats(ewkb, point...)
+		}
+		*g = Geography{EWKB: ewkb, Status: pgtype.Present}
+	case *model.Region:
+		return g.Set(*value)
+	case []byte:
+		*g = Geography{EWKB: value, Status: pgtype.Present}
+	default:
+		return fmt.Errorf("cannot convert %T to Geography", src)
+	}
+	return nil
+}
+
+func (g *Geography) Get() interface{} {
+	if g.Status != pgtype.Present {
+		return nil
+	}
+	return g.EWKB
+}
+
+func (g *Geography) AssignTo(dst interface{}) error {
+	switch value := dst.(type) {
+	case *[]byte:
+		if g.Status != pgtype.Present {
+			*value = nil
+		} else {
+			*value = append([]byte(nil), g.EWKB...)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot assign Geography to %T", dst)
+}
+
+func (g Geography) EncodeBinary(ci *pgtype.ConnInfo, buf []byte) ([]byte, error) {
+	switch g.Status {
+	case pgtype.Null:
+		return nil, nil
+	case pgtype.Undefined:
+		return nil, fmt.Errorf("cannot encode undefined Geography")
+	}
+	return append(buf, g.EWKB...), nil
+}
+
+// encodeEWKB starts a little endian EWKB value of the given geometry type in WGS 84, followed by coords
+func encodeEWKB(geometryType uint32, coords ...float64) []byte {
+	ewkb := []byte{1}
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, geometryType|ewkbSRID)
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, wgs84SRID)
+	return appendFloats(ewkb, coords...)
+}
+
+func appendFloats(buf []byte, values ...float64) []byte {
+	for _, value := range values {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(value))
+	}
+	return buf
+}
+
+// registerSpatialTypes registers Geography for the PostGIS types of the database, if PostGIS is installed
+func registerSpatialTypes(ctx context.Context, conn *pgx.Conn) error {
+	rows, err := conn.Query(ctx, "SELECT typname, oid FROM pg_type WHERE typname IN ('geography', 'geometry')")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var oid uint32
+		if err := rows.Scan(&name, &oid); err != nil {
+			return err
+		}
+		conn.ConnInfo().RegisterDataType(pgtype.DataType{Value: &Geography{}, Name: name, OID: oid})
+	}
+	return rows.Err()
+}
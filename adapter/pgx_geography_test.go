@@ -0,0 +1,39 @@
+package adapter
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+func TestGeographyEncodesEWKB(t *testing.T) {
+	var geography Geography
+	if err := geography.Set(model.NewLocation(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := geography.EncodeBinary(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same as ST_AsEWKB('SRID=4326;POINT(1 2)'::geometry)
+	expected := "0101000020e6100000000000000000f03f0000000000000040"
+	if hex.EncodeToString(buf) != expected {
+		t.Errorf("Expected %s, got %s", expected, hex.EncodeToString(buf))
+	}
+
+	if err := geography.Set(model.NewRectRegion(0, 1, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	// Header, ring count, point count and 5 points of the closed ring
+	if len(geography.EWKB) != 9+4+4+5*16 {
+		t.Errorf("Unexpected polygon length %d", len(geography.EWKB))
+	}
+
+	if err := geography.Set(nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf, _ := geography.EncodeBinary(nil, nil); buf != nil {
+		t.Errorf("Expected NULL encoding, got %v", buf)
+	}
+}
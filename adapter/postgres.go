@@ -1,44 +1,160 @@
 package adapter
 
 import (
+	"context"
 	"database/sql"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
 type PostgresAdapter struct {
-	// TODO: Add connection details
-	conn *sql.DB
+	conn  *sql.DB
+	cache *statementCache // nil if statements are not cached
 }
 
+// Connect opens a connection pool and pings the database, so that an invalid DSN fails immediately
 func (p *PostgresAdapter) Connect(dsn string) error {
-	// TODO: construct dsn from struct
 	conn, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return err
-	} else {
-		p.conn = conn
-		return nil
 	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn = conn
+	return nil
+}
+
+func (p *PostgresAdapter) ConnectWithConfig(config Config) error {
+	if err := p.Connect(config.DSN()); err != nil {
+		return err
+	}
+	if config.StatementCacheSize > 0 {
+		p.cache = newStatementCache(config.StatementCacheSize)
+	}
+	if config.MaxOpenConns > 0 {
+		p.conn.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		p.conn.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		p.conn.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		p.conn.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+	return nil
 }
 
 func (p *PostgresAdapter) Disconnect() error {
 	if p.conn != nil {
+		if p.cache != nil {
+			p.cache.purge()
+		}
 		return p.conn.Close()
 	}
 	return nil
 }
 
-func (p *PostgresAdapter) Query(query string, args ...interface{}) (rows *sql.Rows, err error) {
-	rows, err = p.conn.Query(query, args...)
-	return
+func (p *PostgresAdapter) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if p.conn == nil {
+		return nil, ErrNotConnected
+	}
+	if p.cache != nil && isSchemaChange(query) {
+		defer p.cache.purge()
+	}
+	entry, err := p.prepare(ctx, query, len(args))
+	if err != nil {
+		return nil, err
+	}
+	var rows *sql.Rows
+	if entry != nil {
+		rows, err = entry.stmt.QueryContext(ctx, args...)
+		p.cache.release(entry)
+		if isStalePlan(err) {
+			p.cache.remove(query)
+			rows, err = p.conn.QueryContext(ctx, query, args...)
+		}
+	} else {
+		rows, err = p.conn.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
-func (p *PostgresAdapter) Exec(query string, args ...interface{}) (result sql.Result, err error) {
-	result, err = p.conn.Exec(query, args...)
+func (p *PostgresAdapter) Exec(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	if p.conn == nil {
+		return nil, ErrNotConnected
+	}
+	if p.cache != nil && isSchemaChange(query) {
+		defer p.cache.purge()
+	}
+	entry, err := p.prepare(ctx, query, len(args))
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		result, err = entry.stmt.ExecContext(ctx, args...)
+		p.cache.release(entry)
+		if isStalePlan(err) {
+			p.cache.remove(query)
+			result, err = p.conn.ExecContext(ctx, query, args...)
+		}
+		return
+	}
+	result, err = p.conn.ExecContext(ctx, query, args...)
 	return
 }
 
+// prepare returns the cached prepared statement for the query, preparing it on a cache miss.
+// It returns nil if the query should not be prepared: schema changes, and statements with more than maxCachedArgs
+// arguments, such as chunks of bulk inserts, which are rarely repeated and would hold large statements open on the server.
+func (p *PostgresAdapter) prepare(ctx context.Context, query string, args int) (*cacheEntry, error) {
+	if p.cache == nil || args > maxCachedArgs || isSchemaChange(query) {
+		return nil, nil
+	}
+	if entry, ok := p.cache.get(query); ok {
+		return entry, nil
+	}
+	stmt, err := p.conn.PrepareContext(ctx, query)
+	if err != nil {
+		// Statements that cannot be prepared, e.g. several statements in one string, are run unprepared
+		return nil, nil
+	}
+	return p.cache.put(query, stmt), nil
+}
+
+func (p *PostgresAdapter) Begin(ctx context.Context) (Tx, error) {
+	if p.conn == nil {
+		return nil, ErrNotConnected
+	}
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTx{tx: tx, cache: p.cache}, nil
+}
+
+// StatementCacheStats returns the usage of the prepared statement cache, which is empty if it is disabled
+func (p *PostgresAdapter) StatementCacheStats() CacheStats {
+	if p.cache == nil {
+		return CacheStats{}
+	}
+	return p.cache.snapshot()
+}
+
+// Stats returns the statistics of the connection pool, which are empty before Connect
+func (p *PostgresAdapter) Stats() sql.DBStats {
+	if p.conn == nil {
+		return sql.DBStats{}
+	}
+	return p.conn.Stats()
+}
+
 func (p PostgresAdapter) Placeholder() PlaceholderStyle {
 	return DollarPlaceholder
 }
@@ -50,3 +166,45 @@ func (p PostgresAdapter) SpatialType() SpatialExtension {
 func (p PostgresAdapter) DatabaseType() DbType {
 	return PostgreSQL
 }
+
+func (p PostgresAdapter) Dialect() Dialect {
+	return PostgresDialect{}
+}
+
+// postgresTx runs statements unprepared, the statement cache holds statements of the pool and not of a single connection
+type postgresTx struct {
+	tx            *sql.Tx
+	cache         *statementCache // Purged on commit if the transaction changed the schema
+	schemaChanged bool
+}
+
+func (t *postgresTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if isSchemaChange(query) {
+		t.schemaChanged = true
+	}
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *postgresTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if isSchemaChange(query) {
+		t.schemaChanged = true
+	}
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (t *postgresTx) Commit() error {
+	err := t.tx.Commit()
+	if t.schemaChanged && t.cache != nil {
+		// Plans prepared before the commit may refer to the old schema
+		t.cache.purge()
+	}
+	return err
+}
+
+func (t *postgresTx) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,403 @@
+package adapter
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+type PostgresDialect struct{}
+
+const (
+	postgresDefaultStringSize = 255
+	postgresMaxVarcharSize    = 10485760 // Longer strings are stored as text
+)
+
+func (d PostgresDialect) DatabaseType() DbType {
+	return PostgreSQL
+}
+
+func (d PostgresDialect) SpatialType() SpatialExtension {
+	return PostGisExtension
+}
+
+func (d PostgresDialect) QuoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d PostgresDialect) BindVar(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// MaxParams is the limit of the extended query protocol, which counts parameters in an int16
+func (d PostgresDialect) MaxParams() int {
+	return 65535
+}
+
+func (d PostgresDialect) ColumnType(field *model.Field) (string, error) {
+	if field.CustomType != nil && field.DataType == field.CustomType.DataType {
+		mapping, ok := field.CustomType.MappingFor(string(PostgreSQL))
+		if !ok {
+			return "", unsupported(PostgreSQL, "custom type "+string(field.DataType))
+		}
+		return mapping.ColumnType, nil
+	}
+	if field.AutoIncrement {
+		return "serial", nil
+	}
+	switch field.DataType {
+	case model.Bool:
+		return "bool", nil
+	case model.Int:
+		return "int", nil
+	case model.Uint:
+		return "int", nil // unsigned not supported by postgresql
+	case model.String:
+		if field.Size > postgresMaxVarcharSize {
+			return "text", nil
+		}
+		if field.Size > 0 {
+			return fmt.Sprintf("varchar(%d)", field.Size), nil
+		}
+		return fmt.Sprintf("varchar(%d)", postgresDefaultStringSize), nil
+	case model.Text:
+		return "text", nil
+	case model.Float:
+		return "double precision", nil
+	case model.Time:
+		return "time", nil
+	case model.Bytes:
+		return "bytea", nil
+	case model.LocationType:
+		return "geography(point)", nil
+	case model.RegionType:
+		return "geography(polygon)", nil
+	case model.TimestampType:
+		return "timestamp", nil
+	case model.TimestampTZ:
+		return "timestamptz", nil
+	case model.JSON:
+		return "json", nil
+	case model.JSONB:
+		return "jsonb", nil
+	case "":
+		return "", unsupported(PostgreSQL, "field "+field.Name+" without data type")
+	default:
+		return string(field.DataType), nil
+	}
+}
+
+func (d PostgresDialect) ColumnDefault(value interface{}) (string, error) {
+	if value == nil {
+		return "NULL", nil
+	}
+	switch v := value.(type) {
+	case model.Location, model.Region:
+		val, err := v.(driver.Valuer).Value()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("ST_GeomFromGeoJSON(%s)::geography", d.quoteLiteral(fmt.Sprintf("%s", val))), nil
+	case driver.Valuer:
+		val, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		return d.literal(val)
+	default:
+		return d.literal(v)
+	}
+}
+
+func (d PostgresDialect) SpatialIndex(indexName string, table string, column string, ifNotExists bool) (string, error) {
+	if ifNotExists {
+		indexName = "IF NOT EXISTS " + indexName
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s USING GIST (%s);", indexName, table, column), nil
+}
+
+func (d PostgresDialect) CreateIndex(index Index, ifNotExists bool) (string, error) {
+	sql := strings.Builder{}
+	sql.WriteString("CREATE ")
+	if index.Unique {
+		sql.WriteString("UNIQUE ")
+	}
+	sql.WriteString("INDEX ")
+	if ifNotExists {
+		sql.WriteString("IF NOT EXISTS ")
+	}
+	sql.WriteString(index.Name)
+	sql.WriteString(" ON ")
+	sql.WriteString(index.Table)
+	if index.Method != "" {
+		sql.WriteString(" USING ")
+		sql.WriteString(strings.ToUpper(index.Method))
+	}
+	sql.WriteString(" (")
+	sql.WriteString(strings.Join(index.Keys, ", "))
+	sql.WriteString(")")
+	if len(index.Include) > 0 {
+		sql.WriteString(" INCLUDE (")
+		sql.WriteString(strings.Join(index.Include, ", "))
+		sql.WriteString(")")
+	}
+	if index.Where != "" {
+		sql.WriteString(" WHERE ")
+		sql.WriteString(index.Where)
+	}
+	sql.WriteString(";")
+	return sql.String(), nil
+}
+
+// DropIndex does not need the table, index names are unique within a Postgres schema
+func (d PostgresDialect) DropIndex(indexName string, table string, ifExists bool) (string, error) {
+	if ifExists {
+		indexName = "IF EXISTS " + indexName
+	}
+	return fmt.Sprintf("DROP INDEX %s;", indexName), nil
+}
+
+func (d PostgresDialect) RenameIndex(indexName string, table string, newIndexName string) (string, error) {
+	return fmt.Sprintf("ALTER INDEX IF EXISTS %s RENAME TO %s;", indexName, newIndexName), nil
+}
+
+func (d PostgresDialect) DropTable(table string, ifExists bool, cascade bool) (string, error) {
+	if ifExists {
+		table = "IF EXISTS " + table
+	}
+	if cascade {
+		return fmt.Sprintf("DROP TABLE %s CASCADE;", table), nil
+	}
+	return fmt.Sprintf("DROP TABLE %s;", table), nil
+}
+
+func (d PostgresDialect) TruncateTable(table string, cascade bool) (string, error) {
+	if cascade {
+		return fmt.Sprintf("TRUNCATE TABLE %s CASCADE;", table), nil
+	}
+	return fmt.Sprintf("TRUNCATE TABLE %s;", table), nil
+}
+
+func (d PostgresDialect) RenameTable(table string, newTable string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", table, newTable), nil
+}
+
+func (d PostgresDialect) AlterColumnType(table string, column string, columnType string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, column, columnType), nil
+}
+
+func (d PostgresDialect) AdvisoryLock(placeholder string) (string, error) {
+	return fmt.Sprintf("SELECT pg_advisory_xact_lock(%s);", placeholder), nil
+}
+
+func (d PostgresDialect) CreateMigrationsTable(table string) (string, error) {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamptz NOT NULL DEFAULT now());", table), nil
+}
+
+func (d PostgresDialect) ColumnsQuery() (string, error) {
+	return `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull
+FROM pg_attribute a
+WHERE a.attrelid = to_regclass(quote_ident($1)) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, nil
+}
+
+func (d PostgresDialect) IndexesQuery() (string, error) {
+	return `SELECT i.relname, x.indisunique, x.indisprimary, coalesce(array_to_string(array(
+	SELECT a.attname FROM unnest(x.indkey::int2[]) WITH ORDINALITY AS k(attnum, n)
+	JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum
+	ORDER BY k.n), ','), '')
+FROM pg_index x
+JOIN pg_class i ON i.oid = x.indexrelid
+WHERE x.indrelid = to_regclass(quote_ident($1))
+ORDER BY i.relname`, nil
+}
+
+func (d PostgresDialect) CompareColumnType(existing string, wanted string) TypeChange {
+	existingName, existingModifier := d.parseType(existing)
+	wantedName, wantedModifier := d.parseType(wanted)
+	if existingName == wantedName && existingModifier == wantedModifier {
+		return TypeUnchanged
+	}
+	if existingName != "character varying" || existingModifier == "" {
+		return TypeChanged
+	}
+	switch wantedName {
+	case "text":
+		return TypeWidened
+	case "character varying":
+		if wantedModifier == "" {
+			return TypeWidened
+		}
+		existingLength, err := strconv.Atoi(existingModifier)
+		if err != nil {
+			return TypeChanged
+		}
+		wantedLength, err := strconv.Atoi(wantedModifier)
+		if err == nil && wantedLength > existingLength {
+			return TypeWidened
+		}
+	}
+	return TypeChanged
+}
+
+// postgresTypeNames maps alternative type names to the names reported by format_type
+var postgresTypeNames = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"bool":        "boolean",
+	"float4":      "real",
+	"float8":      "double precision",
+	"float":       "double precision",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+}
+
+// parseType splits a column type into its canonical name and type modifier, e.g. varchar(255) into character varying and 255
+func (d PostgresDialect) parseType(columnType string) (string, string) {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	name, modifier := columnType, ""
+	if start := strings.Index(columnType, "("); start >= 0 && strings.HasSuffix(columnType, ")") {
+		name = strings.TrimSpace(columnType[:start])
+		modifier = strings.ReplaceAll(columnType[start+1:len(columnType)-1], " ", "")
+	}
+	if canonical, ok := postgresTypeNames[name]; ok {
+		name = canonical
+	}
+	if name == "geography" {
+		// 4326 is the default SRID of geography columns, format_type reports it even when it was not specified
+		modifier = strings.TrimSuffix(modifier, ",4326")
+	}
+	return name, modifier
+}
+
+func (d PostgresDialect) EncodeSpatial(placeholder string) (string, error) {
+	return d.geography(ParamArg(placeholder)), nil
+}
+
+func (d PostgresDialect) DecodeSpatial(column string) (string, error) {
+	return fmt.Sprintf("ST_AsGeoJSON(%s)", column), nil
+}
+
+func (d PostgresDialect) SpatialPredicate(predicate SpatialPredicate, args ...SpatialArg) (string, error) {
+	switch predicate {
+	case SpatialEquals:
+		if len(args) != 2 {
+			break
+		}
+		return fmt.Sprintf("ST_Equals(%s, %s)", d.geometry(args[0]), d.geometry(args[1])), nil
+	case SpatialCovers:
+		if len(args) != 2 {
+			break
+		}
+		return fmt.Sprintf("ST_Covers(%s, %s)", d.geography(args[0]), d.geography(args[1])), nil
+	case SpatialDWithin:
+		if len(args) != 3 {
+			break
+		}
+		return fmt.Sprintf("ST_DWithin(%s, %s, %s)", d.geography(args[0]), d.geography(args[1]), args[2].Expr), nil
+	default:
+		return "", unsupported(PostgreSQL, string(predicate))
+	}
+	return "", fmt.Errorf("wrong number of arguments for %s: %d", predicate, len(args))
+}
+
+func (d PostgresDialect) SpatialDistance(a SpatialArg, b SpatialArg) (string, error) {
+	return fmt.Sprintf("%s <#> %s", d.geometry(a), d.geometry(b)), nil
+}
+
+func (d PostgresDialect) JSONPath(column string, path []string) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("empty json path for %s", column)
+	}
+	expression := strings.Builder{}
+	expression.WriteString(column)
+	for i, key := range path {
+		if i < len(path)-1 {
+			expression.WriteString("->")
+		} else {
+			expression.WriteString("->>")
+		}
+		expression.WriteString(d.quoteLiteral(key))
+	}
+	return expression.String(), nil
+}
+
+func (d PostgresDialect) JSONPredicate(predicate JSONPredicate, column string, placeholder string) (string, error) {
+	switch predicate {
+	case JSONContains:
+		return fmt.Sprintf("%s @> %s::jsonb", column, placeholder), nil
+	case JSONHasKey:
+		return fmt.Sprintf("%s ? %s", column, placeholder), nil
+	default:
+		return "", unsupported(PostgreSQL, string(predicate))
+	}
+}
+
+func (d PostgresDialect) geometry(arg SpatialArg) string {
+	if arg.IsParam {
+		return fmt.Sprintf("ST_GeomFromGeoJSON(%s)", arg.Expr)
+	}
+	return arg.Expr + "::geometry"
+}
+
+func (d PostgresDialect) geography(arg SpatialArg) string {
+	if arg.IsParam {
+		return fmt.Sprintf("ST_GeomFromGeoJSON(%s)::geography", arg.Expr)
+	}
+	return arg.Expr
+}
+
+func (d PostgresDialect) quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// literal renders the value as an SQL literal, or reports an error for values of unsupported types
+func (d PostgresDialect) literal(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return d.quoteLiteral(v), nil
+	case []byte:
+		return d.quoteLiteral(string(v)), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return d.quoteLiteral(v.Format(time.RFC3339Nano)), nil
+	}
+	// Numbers are matched by kind, so that named types such as enums of int are supported as well
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflected.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(reflected.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(reflected.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(reflected.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return d.quoteLiteral(reflected.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(reflected.Bool()), nil
+	}
+	return "", unsupported(PostgreSQL, fmt.Sprintf("default values of type %T", value))
+}
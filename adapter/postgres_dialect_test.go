@@ -0,0 +1,101 @@
+package adapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+func TestStringColumnTypes(t *testing.T) {
+	dialect := PostgresDialect{}
+	cases := []struct {
+		field    model.Field
+		expected string
+	}{
+		{model.Field{DataType: model.String}, "varchar(255)"},
+		{model.Field{DataType: model.String, Size: 1000}, "varchar(1000)"},
+		{model.Field{DataType: model.String, Size: 20000000}, "text"},
+		{model.Field{DataType: model.Text}, "text"},
+	}
+	for _, c := range cases {
+		columnType, err := dialect.ColumnType(&c.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if columnType != c.expected {
+			t.Errorf("Expected %s for size %d, got %s", c.expected, c.field.Size, columnType)
+		}
+	}
+}
+
+func TestCompareColumnType(t *testing.T) {
+	dialect := PostgresDialect{}
+	cases := []struct {
+		existing string
+		wanted   string
+		change   TypeChange
+	}{
+		{"integer", "int", TypeUnchanged},
+		{"integer", "serial", TypeUnchanged},
+		{"character varying(255)", "varchar(255)", TypeUnchanged},
+		{"geography(Point,4326)", "geography(point)", TypeUnchanged},
+		{"timestamp without time zone", "timestamp", TypeUnchanged},
+		{"double precision", "double precision", TypeUnchanged},
+		{"character varying(100)", "varchar(255)", TypeWidened},
+		{"character varying(100)", "text", TypeWidened},
+		{"character varying(255)", "varchar(100)", TypeChanged},
+		{"text", "varchar(255)", TypeChanged},
+		{"bigint", "int", TypeChanged},
+		{"geography(Polygon,4326)", "geography(point)", TypeChanged},
+	}
+	for _, c := range cases {
+		if change := dialect.CompareColumnType(c.existing, c.wanted); change != c.change {
+			t.Errorf("CompareColumnType(%q, %q) = %d, expected %d", c.existing, c.wanted, change, c.change)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	dialect := PostgresDialect{}
+	path, err := dialect.JSONPath(`"attrs"`, []string{"battery", "owner's"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `"attrs"->'battery'->>'owner''s'`; path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+	if _, err := dialect.JSONPath(`"attrs"`, nil); err == nil {
+		t.Errorf("Expected error for empty path")
+	}
+}
+
+type defaultLevel int
+
+func TestColumnDefault(t *testing.T) {
+	dialect := PostgresDialect{}
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{"it's", "'it''s'"},
+		{true, "true"},
+		{int8(-3), "-3"},
+		{uint64(7), "7"},
+		{2.5, "2.5"},
+		{defaultLevel(2), "2"},
+	}
+	for _, c := range cases {
+		value, err := dialect.ColumnDefault(c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != c.expected {
+			t.Errorf("Expected %s for %#v, got %s", c.expected, c.value, value)
+		}
+	}
+	if _, err := dialect.ColumnDefault([]string{"a"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected unsupported error for a slice, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package adapter
+
+import (
+	"container/list"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgconn"
+)
+
+// maxCachedArgs is the largest number of arguments of a statement that is prepared and cached
+const maxCachedArgs = 1000
+
+// CacheStats reports the usage of a prepared statement cache
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// StatementCacher is implemented by adapters that keep a cache of prepared statements
+type StatementCacher interface {
+	StatementCacheStats() CacheStats
+}
+
+type cacheEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	users   int
+	evicted bool
+}
+
+// statementCache is an LRU cache of prepared statements keyed by their SQL.
+// Evicted statements are closed once no caller is executing them anymore.
+type statementCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	stats    CacheStats
+	close    func(stmt *sql.Stmt)
+}
+
+func newStatementCache(capacity int) *statementCache {
+	return &statementCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		close:    func(stmt *sql.Stmt) { stmt.Close() },
+	}
+}
+
+// get returns the cached statement for the query, which must be given back with release
+func (c *statementCache) get(query string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[query]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	entry := element.Value.(*cacheEntry)
+	entry.users++
+	return entry, true
+}
+
+// put caches a newly prepared statement, which must be given back with release
+func (c *statementCache) put(query string, stmt *sql.Stmt) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[query]; ok {
+		// Prepared concurrently by another caller, keep using the new statement until released
+		return &cacheEntry{query: query, stmt: stmt, users: 1, evicted: true}
+	}
+	entry := &cacheEntry{query: query, stmt: stmt, users: 1}
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.stats.Evictions++
+		c.evict(c.order.Back())
+	}
+	return entry
+}
+
+func (c *statementCache) release(entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry.users--
+	if entry.evicted && entry.users == 0 {
+		c.close(entry.stmt)
+	}
+}
+
+// remove drops the statement of the query, e.g. after its plan became invalid
+func (c *statementCache) remove(query string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[query]; ok {
+		c.evict(element)
+	}
+}
+
+// purge drops all statements, as their plans may refer to a changed schema
+func (c *statementCache) purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+func (c *statementCache) evict(element *list.Element) {
+	entry := c.order.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.users == 0 {
+		c.close(entry.stmt)
+	}
+}
+
+func (c *statementCache) snapshot() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+var schemaChangeKeywords = []string{"CREATE", "ALTER", "DROP", "TRUNCATE", "COMMENT"}
+
+// isSchemaChange reports whether the statement is DDL, which is not prepared and invalidates cached statements
+func isSchemaChange(query string) bool {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	for _, keyword := range schemaChangeKeywords {
+		if strings.HasPrefix(query, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStalePlan reports whether a prepared statement failed because the schema it was planned for changed,
+// e.g. "cached plan must not change result type" after a column was added by another client
+func isStalePlan(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "0A000"
+}
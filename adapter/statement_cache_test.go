@@ -0,0 +1,264 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestCache(capacity int, closed *[]*sql.Stmt) *statementCache {
+	cache := newStatementCache(capacity)
+	cache.close = func(stmt *sql.Stmt) { *closed = append(*closed, stmt) }
+	return cache
+}
+
+func TestStatementCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	closed := make([]*sql.Stmt, 0)
+	cache := newTestCache(2, &closed)
+	first, second, third := &sql.Stmt{}, &sql.Stmt{}, &sql.Stmt{}
+
+	cache.release(cache.put("SELECT 1", first))
+	cache.release(cache.put("SELECT 2", second))
+	entry, ok := cache.get("SELECT 1")
+	if !ok || entry.stmt != first {
+		t.Fatalf("Expected cached statement")
+	}
+	cache.release(entry)
+	cache.release(cache.put("SELECT 3", third))
+
+	if _, ok := cache.get("SELECT 2"); ok {
+		t.Errorf("Expected least recently used statement to be evicted")
+	}
+	if len(closed) != 1 || closed[0] != second {
+		t.Errorf("Expected evicted statement to be closed, got %v", closed)
+	}
+	stats := cache.snapshot()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 || stats.Size != 2 || stats.HitRate() != 0.5 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestStatementCacheClosesAfterRelease(t *testing.T) {
+	closed := make([]*sql.Stmt, 0)
+	cache := newTestCache(2, &closed)
+	entry := cache.put("SELECT 1", &sql.Stmt{})
+	cache.purge()
+	if len(closed) != 0 {
+		t.Fatalf("Statement in use should not be closed")
+	}
+	if _, ok := cache.get("SELECT 1"); ok {
+		t.Errorf("Expected purged statement to be gone")
+	}
+	cache.release(entry)
+	if len(closed) != 1 || closed[0] != entry.stmt {
+		t.Errorf("Expected statement to be closed once released")
+	}
+}
+
+func TestIsSchemaChange(t *testing.T) {
+	for query, expected := range map[string]bool{
+		"  create table car (id int);":     true,
+		"ALTER TABLE car ADD COLUMN x int": true,
+		`SELECT "drop" FROM car`:           false,
+		"INSERT INTO car VALUES ($1)":      false,
+	} {
+		if isSchemaChange(query) != expected {
+			t.Errorf("Expected isSchemaChange(%q) to be %v", query, expected)
+		}
+	}
+}
+
+// recordingDriver is a database/sql driver recording the statements it prepares and runs.
+// Statements containing a semicolon followed by another statement cannot be prepared.
+type recordingDriver struct {
+	log *[]string
+}
+
+func (d recordingDriver) Open(name string) (driver.Conn, error) {
+	return recordingConn{log: d.log}, nil
+}
+
+type recordingConn struct {
+	log *[]string
+}
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(strings.TrimSuffix(strings.TrimSpace(query), ";"), ";") {
+		return nil, errors.New("cannot insert multiple commands into a prepared statement")
+	}
+	*c.log = append(*c.log, "PREPARE "+query)
+	return recordingStmt{log: c.log, query: query}, nil
+}
+
+func (c recordingConn) Close() error { return nil }
+
+func (c recordingConn) Begin() (driver.Tx, error) {
+	*c.log = append(*c.log, "BEGIN")
+	return recordingTx{log: c.log}, nil
+}
+
+func (c recordingConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	*c.log = append(*c.log, "EXEC "+query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c recordingConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	*c.log = append(*c.log, "QUERY "+query)
+	return recordingRows{}, nil
+}
+
+type recordingTx struct {
+	log *[]string
+}
+
+func (t recordingTx) Commit() error {
+	*t.log = append(*t.log, "COMMIT")
+	return nil
+}
+
+func (t recordingTx) Rollback() error {
+	*t.log = append(*t.log, "ROLLBACK")
+	return nil
+}
+
+type recordingStmt struct {
+	log   *[]string
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.log = append(*s.log, "EXEC PREPARED "+s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	*s.log = append(*s.log, "QUERY PREPARED "+s.query)
+	return recordingRows{}, nil
+}
+
+type recordingRows struct{}
+
+func (r recordingRows) Columns() []string              { return nil }
+func (r recordingRows) Close() error                   { return nil }
+func (r recordingRows) Next(dest []driver.Value) error { return io.EOF }
+
+var recordingDriverCount int
+
+func newRecordingAdapter(t *testing.T) (*PostgresAdapter, *[]string) {
+	log := make([]string, 0)
+	recordingDriverCount++
+	name := fmt.Sprintf("atlas-recording-%d", recordingDriverCount)
+	sql.Register(name, recordingDriver{log: &log})
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &PostgresAdapter{conn: conn, cache: newStatementCache(256)}, &log
+}
+
+func TestSchemaChangeInTransactionPurgesCache(t *testing.T) {
+	postgres, log := newRecordingAdapter(t)
+	ctx := context.Background()
+	query := `SELECT "id" FROM "car"`
+
+	rows, err := postgres.Query(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if stats := postgres.StatementCacheStats(); stats.Size != 1 {
+		t.Fatalf("Expected cached statement, got %+v", stats)
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, `ALTER TABLE "car" ADD COLUMN "brand" varchar(255)`); err != nil {
+		t.Fatal(err)
+	}
+	if stats := postgres.StatementCacheStats(); stats.Size != 1 {
+		t.Errorf("Expected cache to be kept until commit, got %+v", stats)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := postgres.StatementCacheStats(); stats.Size != 0 {
+		t.Errorf("Expected cache to be purged by commit, got %+v", stats)
+	}
+
+	rows, err = postgres.Query(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	prepares := 0
+	for _, entry := range *log {
+		if entry == "PREPARE "+query {
+			prepares++
+		}
+	}
+	if prepares != 2 {
+		t.Errorf("Expected select to be prepared again after the schema change, got %v", *log)
+	}
+}
+
+func TestUnpreparableStatementRunsUnprepared(t *testing.T) {
+	postgres, log := newRecordingAdapter(t)
+	query := `UPDATE "car" SET "brand" = 'a'; UPDATE "car" SET "model" = 'b';`
+	if _, err := postgres.Exec(context.Background(), query); err != nil {
+		t.Fatal(err)
+	}
+	if last := (*log)[len(*log)-1]; last != "EXEC "+query {
+		t.Errorf("Expected unprepared exec, got %v", *log)
+	}
+	if stats := postgres.StatementCacheStats(); stats.Size != 0 {
+		t.Errorf("Expected nothing cached, got %+v", stats)
+	}
+}
+
+func TestSchemaChangeThroughQueryPurgesCache(t *testing.T) {
+	postgres, _ := newRecordingAdapter(t)
+	ctx := context.Background()
+	rows, err := postgres.Query(ctx, `SELECT "id" FROM "car"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	rows, err = postgres.Query(ctx, `ALTER TABLE "car" ADD COLUMN "brand" varchar(255)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if stats := postgres.StatementCacheStats(); stats.Size != 0 {
+		t.Errorf("Expected cache to be purged, got %+v", stats)
+	}
+}
+
+func TestStatementWithManyArgsRunsUnprepared(t *testing.T) {
+	postgres, log := newRecordingAdapter(t)
+	query := `INSERT INTO "car" ("id") VALUES ($1)`
+	args := make([]interface{}, maxCachedArgs+1)
+	for i := range args {
+		args[i] = i
+	}
+	if _, err := postgres.Exec(context.Background(), query, args...); err != nil {
+		t.Fatal(err)
+	}
+	if last := (*log)[len(*log)-1]; last != "EXEC "+query {
+		t.Errorf("Expected unprepared exec, got %v", *log)
+	}
+	if stats := postgres.StatementCacheStats(); stats.Size != 0 {
+		t.Errorf("Expected nothing cached, got %+v", stats)
+	}
+}
@@ -0,0 +1,341 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+type SchemaChangeKind string
+
+const (
+	ChangeCreateTable SchemaChangeKind = "create_table"
+	ChangeAddColumn   SchemaChangeKind = "add_column"
+	ChangeAddIndex    SchemaChangeKind = "add_index"
+	ChangeWidenColumn SchemaChangeKind = "widen_column"
+	ChangeColumnType  SchemaChangeKind = "column_type" // Column type differs in a way that may not convert existing values
+	ChangeDropColumn  SchemaChangeKind = "drop_column" // Column exists in the table but not in the model
+	ChangeNullability SchemaChangeKind = "nullability" // Column nullability differs from the model
+	ChangeUnique      SchemaChangeKind = "unique"      // Unique field without a unique index on its column
+	ChangePrimaryKey  SchemaChangeKind = "primary_key" // Primary key columns differ from the model
+)
+
+// SchemaChange is a difference between a registered model and its table in the database
+type SchemaChange struct {
+	Kind       SchemaChangeKind
+	Model      string
+	Table      string
+	Column     string
+	Index      string
+	Detail     string
+	Statements []string // Statements applying the change, only set for changes applied by AutoMigrate
+}
+
+func (c SchemaChange) String() string {
+	target := c.Table
+	if c.Column != "" {
+		target += "." + c.Column
+	}
+	if c.Index != "" {
+		target += " index " + c.Index
+	}
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s", c.Kind, target)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, target, c.Detail)
+}
+
+// MigrationReport lists the changes made by AutoMigrate and the differences it left in place
+type MigrationReport struct {
+	Applied []SchemaChange
+	// Pending differences could lose data or fail on existing rows, they have to be applied with a migration (see package migrate)
+	Pending []SchemaChange
+}
+
+type existingColumn struct {
+	name       string
+	columnType string
+	nullable   bool
+}
+
+type existingIndex struct {
+	name    string
+	unique  bool
+	primary bool
+	columns []string
+}
+
+func (d *Database) AutoMigrate(models ...interface{}) (*MigrationReport, error) {
+	return d.AutoMigrateContext(context.Background(), models...)
+}
+
+// AutoMigrateContext compares the tables of the models with their definitions and applies the additive changes:
+// missing tables, columns and indexes are created and varchar columns are widened.
+// Models that are not registered yet are registered first.
+// Other differences are only reported in MigrationReport.Pending. All changes are applied in a single transaction.
+func (d *Database) AutoMigrateContext(ctx context.Context, models ...interface{}) (*MigrationReport, error) {
+	report := &MigrationReport{}
+	for _, target := range models {
+		schema, err := d.getSchema(target)
+		if errors.Is(err, ErrNoSchema) {
+			if err = d.RegisterModel(target); err == nil {
+				schema, err = d.getSchema(target)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		columns, indexes, err := d.introspect(ctx, schema)
+		if err != nil {
+			return nil, err
+		}
+		applied, pending, err := diffTable(NewQuery(schema, d), columns, indexes)
+		if err != nil {
+			return nil, err
+		}
+		report.Applied = append(report.Applied, applied...)
+		report.Pending = append(report.Pending, pending...)
+	}
+	if len(report.Applied) == 0 {
+		return report, nil
+	}
+
+	err := d.Transaction(ctx, func(tx adapter.Tx) error {
+		for _, change := range report.Applied {
+			operation := OpAlterTable
+			if change.Kind == ChangeCreateTable {
+				operation = OpCreateTable
+			}
+			event := &QueryEvent{Operation: operation, Model: change.Model, Table: change.Table, Statement: strings.Join(change.Statements, "\n")}
+			err := d.run(ctx, event, func(ctx context.Context) (int64, error) {
+				for _, statement := range change.Statements {
+					if _, err := tx.Exec(ctx, statement); err != nil {
+						return 0, err
+					}
+				}
+				return 0, nil
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", change, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// introspect reads the columns and indexes of the table of the schema
+func (d *Database) introspect(ctx context.Context, schema model.Schema) ([]existingColumn, []existingIndex, error) {
+	dialect := d.adapter.Dialect()
+	columnsQuery, err := dialect.ColumnsQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := d.QueryRowsContext(ctx, columnsQuery, schema.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make([]existingColumn, 0, len(schema.Fields))
+	for rows.Next() {
+		column := existingColumn{}
+		if err := rows.Scan(&column.name, &column.columnType, &column.nullable); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		columns = append(columns, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return columns, nil, nil
+	}
+
+	indexesQuery, err := dialect.IndexesQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err = d.QueryRowsContext(ctx, indexesQuery, schema.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	indexes := make([]existingIndex, 0)
+	for rows.Next() {
+		index := existingIndex{}
+		var indexColumns string
+		if err := rows.Scan(&index.name, &index.unique, &index.primary, &indexColumns); err != nil {
+			return nil, nil, err
+		}
+		if indexColumns != "" {
+			index.columns = strings.Split(indexColumns, ",")
+		}
+		indexes = append(indexes, index)
+	}
+	return columns, indexes, rows.Err()
+}
+
+// diffTable compares the existing columns and indexes of a table with the schema of the query.
+// A table without columns does not exist yet and is created.
+func diffTable(q *Query, columns []existingColumn, indexes []existingIndex) (applied []SchemaChange, pending []SchemaChange, err error) {
+	schema := q.mainSchema
+	dialect := q.GetAdapterInfo().Dialect()
+	change := func(kind SchemaChangeKind, column string, detail string) SchemaChange {
+		return SchemaChange{Kind: kind, Model: schema.Name, Table: schema.Table, Column: column, Detail: detail}
+	}
+
+	if len(columns) == 0 {
+		table, err := query.CompileTableCreation(q, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		statements, err := query.CompileIndexCreation(q, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		created := change(ChangeCreateTable, "", "")
+		created.Statements = append([]string{table}, statements...)
+		return []SchemaChange{created}, nil, nil
+	}
+
+	quotedTable := dialect.QuoteIdentifier(schema.Table)
+	existing := make(map[string]existingColumn, len(columns))
+	for _, column := range columns {
+		existing[column.name] = column
+	}
+	for _, field := range schema.Fields {
+		wantedType, err := dialect.ColumnType(field)
+		if err != nil {
+			return nil, nil, err
+		}
+		column, ok := existing[field.DBName]
+		if !ok {
+			if (field.PrimaryKey || field.NotNull) && !field.HasDefaultValue && !field.AutoIncrement {
+				pending = append(pending, change(ChangeAddColumn, field.DBName, "NOT NULL column without default cannot be added to a table with rows"))
+				continue
+			}
+			added := change(ChangeAddColumn, field.DBName, wantedType)
+			statement, err := query.CompileColumnAddition(q, field.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			added.Statements = []string{statement}
+			applied = append(applied, added)
+			continue
+		}
+
+		detail := fmt.Sprintf("%s to %s", column.columnType, wantedType)
+		switch dialect.CompareColumnType(column.columnType, wantedType) {
+		case adapter.TypeWidened:
+			widened := change(ChangeWidenColumn, field.DBName, detail)
+			statement, err := dialect.AlterColumnType(quotedTable, dialect.QuoteIdentifier(field.DBName), wantedType)
+			if err != nil {
+				return nil, nil, err
+			}
+			widened.Statements = []string{statement}
+			applied = append(applied, widened)
+		case adapter.TypeChanged:
+			pending = append(pending, change(ChangeColumnType, field.DBName, detail))
+		}
+
+		wantedNullable := !field.PrimaryKey && !field.NotNull
+		if column.nullable && !wantedNullable {
+			pending = append(pending, change(ChangeNullability, field.DBName, "column is nullable, model is not null"))
+		} else if !column.nullable && wantedNullable {
+			pending = append(pending, change(ChangeNullability, field.DBName, "column is not null, model is nullable"))
+		}
+		if field.Unique && !field.PrimaryKey && !hasIndexOn(indexes, []string{field.DBName}, true) {
+			pending = append(pending, change(ChangeUnique, field.DBName, "no unique index on column"))
+		}
+	}
+	for _, column := range columns {
+		if _, ok := schema.FieldsByDBName[column.name]; !ok {
+			pending = append(pending, change(ChangeDropColumn, column.name, "column is not in the model"))
+		}
+	}
+
+	primaryColumns := make([]string, 0, len(schema.PrimaryFields))
+	for _, field := range schema.PrimaryFields {
+		primaryColumns = append(primaryColumns, field.DBName)
+	}
+	existingPrimary := []string{}
+	for _, index := range indexes {
+		if index.primary {
+			existingPrimary = index.columns
+		}
+	}
+	if strings.Join(primaryColumns, ",") != strings.Join(existingPrimary, ",") {
+		pending = append(pending, change(ChangePrimaryKey, "", fmt.Sprintf("(%s) to (%s)", strings.Join(existingPrimary, ", "), strings.Join(primaryColumns, ", "))))
+	}
+
+	definitions, err := query.CompileIndexes(q, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, definition := range definitions {
+		if !hasColumns(definition.Columns, existing, applied) {
+			// Indexes on columns that could not be added are left for the migration adding the columns
+			continue
+		}
+		if hasIndexNamed(indexes, definition.Name) || (definition.Plain && hasIndexOn(indexes, definition.Columns, definition.Unique)) {
+			continue
+		}
+		added := change(ChangeAddIndex, "", strings.Join(definition.Columns, ", "))
+		added.Index = definition.Name
+		if definition.Unique {
+			added.Detail = "unique index fails on duplicate values"
+			pending = append(pending, added)
+			continue
+		}
+		added.Statements = []string{definition.Statement}
+		applied = append(applied, added)
+	}
+	return applied, pending, nil
+}
+
+func hasIndexNamed(indexes []existingIndex, name string) bool {
+	for _, index := range indexes {
+		if index.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIndexOn reports whether an index covers exactly the columns, in order. A unique index also serves a non unique one.
+func hasIndexOn(indexes []existingIndex, columns []string, unique bool) bool {
+	for _, index := range indexes {
+		if (index.unique || !unique) && strings.Join(index.columns, ",") == strings.Join(columns, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasColumns reports whether the columns exist or are added by the applied changes
+func hasColumns(columns []string, existing map[string]existingColumn, applied []SchemaChange) bool {
+	for _, column := range columns {
+		if _, ok := existing[column]; ok {
+			continue
+		}
+		added := false
+		for _, change := range applied {
+			if change.Kind == ChangeAddColumn && change.Column == column {
+				added = true
+			}
+		}
+		if !added {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package atlas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+type MigrateTest struct {
+	Id       int `atlas:"primarykey"`
+	Brand    string
+	Price    float64
+	Location model.Location
+}
+
+func TestAutoMigrateAppliesAdditiveChanges(t *testing.T) {
+	fake := &fakeAdapter{results: []*fakeRows{
+		{values: [][]interface{}{
+			{"id", "integer", false},
+			{"brand", "character varying(100)", true},
+			{"location", "geography(Point,4326)", true},
+			{"legacy", "text", true},
+		}},
+		{values: [][]interface{}{
+			{"migrate_test_pkey", true, true, "id"},
+		}},
+	}}
+	db := newFakeDatabase(fake)
+
+	report, err := db.AutoMigrate(MigrateTest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`ALTER TABLE "migrate_test" ALTER COLUMN "brand" TYPE varchar(255);`,
+		`ALTER TABLE "migrate_test" ADD COLUMN "price" double precision;`,
+		`CREATE INDEX IF NOT EXISTS "idx_migrate_test_location" ON "migrate_test" USING GIST ("location");`,
+	}
+	if !reflect.DeepEqual(fake.statements[2:], expected) {
+		t.Errorf("Unexpected statements:\n%q\nexpected:\n%q", fake.statements[2:], expected)
+	}
+	if len(report.Applied) != 3 || report.Applied[0].Kind != ChangeWidenColumn || report.Applied[1].Kind != ChangeAddColumn || report.Applied[2].Kind != ChangeAddIndex {
+		t.Errorf("Unexpected applied changes: %v", report.Applied)
+	}
+	if len(report.Pending) != 1 || report.Pending[0].Kind != ChangeDropColumn || report.Pending[0].Column != "legacy" {
+		t.Errorf("Unexpected pending changes: %v", report.Pending)
+	}
+	if fake.commits != 1 {
+		t.Errorf("Expected changes to be committed once, got %d", fake.commits)
+	}
+}
+
+func TestAutoMigrateReportsDestructiveChanges(t *testing.T) {
+	fake := &fakeAdapter{results: []*fakeRows{
+		{values: [][]interface{}{
+			{"id", "bigint", false},
+			{"brand", "text", false},
+			{"price", "double precision", true},
+			{"location", "geography(Point,4326)", true},
+		}},
+		{values: [][]interface{}{
+			{"idx_migrate_test_location", false, false, "location"},
+		}},
+	}}
+	db := newFakeDatabase(fake)
+
+	report, err := db.AutoMigrate(MigrateTest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Applied) != 0 || len(fake.statements) != 2 {
+		t.Errorf("Expected no changes to be applied, got %v", report.Applied)
+	}
+	kinds := []SchemaChangeKind{}
+	for _, change := range report.Pending {
+		kinds = append(kinds, change.Kind)
+	}
+	expected := []SchemaChangeKind{ChangeColumnType, ChangeColumnType, ChangeNullability, ChangePrimaryKey}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Unexpected pending changes: %v", report.Pending)
+	}
+}
+
+func TestAutoMigrateCreatesMissingTable(t *testing.T) {
+	fake := &fakeAdapter{results: []*fakeRows{{}}}
+	db := newFakeDatabase(fake)
+
+	report, err := db.AutoMigrate(MigrateTest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`CREATE TABLE "migrate_test" ("id" int PRIMARY KEY, "brand" varchar(255), "price" double precision, "location" geography(point));`,
+		`CREATE INDEX "idx_migrate_test_location" ON "migrate_test" USING GIST ("location");`,
+	}
+	if !reflect.DeepEqual(fake.statements[1:], expected) {
+		t.Errorf("Unexpected statements:\n%q\nexpected:\n%q", fake.statements[1:], expected)
+	}
+	if len(report.Applied) != 1 || report.Applied[0].Kind != ChangeCreateTable {
+		t.Errorf("Unexpected applied changes: %v", report.Applied)
+	}
+}
+
+type MultilineDefaultTest struct {
+	Id   int    `atlas:"primarykey"`
+	Note string `atlas:"default"`
+}
+
+func TestAutoMigrateKeepsMultilineStatements(t *testing.T) {
+	fake := &fakeAdapter{results: []*fakeRows{{}}}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(MultilineDefaultTest{Note: "first\nsecond"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := db.AutoMigrate(MultilineDefaultTest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"CREATE TABLE \"multiline_default_test\" (\"id\" int PRIMARY KEY, \"note\" varchar(255) DEFAULT 'first\nsecond');"}
+	if !reflect.DeepEqual(fake.statements[1:], expected) {
+		t.Errorf("Unexpected statements:\n%q\nexpected:\n%q", fake.statements[1:], expected)
+	}
+	if len(report.Applied) != 1 || !reflect.DeepEqual(report.Applied[0].Statements, expected) {
+		t.Errorf("Unexpected applied changes: %v", report.Applied)
+	}
+}
@@ -0,0 +1,212 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+type BulkMethod string
+
+const (
+	BulkAuto   BulkMethod = ""       // COPY if the adapter supports it, INSERT otherwise
+	BulkInsert BulkMethod = "insert" // Multi-row INSERT statements
+	BulkCopy   BulkMethod = "copy"   // COPY FROM STDIN, requires an adapter implementing adapter.CopyFromer
+)
+
+const defaultCopyChunkSize = 10000
+
+type BulkOptions struct {
+	Method BulkMethod
+	// ChunkSize is the number of rows loaded per statement. It defaults to 10000 rows for COPY,
+	// and to the most rows the parameter limit of the dialect allows for INSERT.
+	ChunkSize int
+	// Omit leaves fields out of the load, e.g. so that they are set to their database default
+	Omit []string
+	// ContinueOnError keeps loading the following chunks after a chunk fails
+	ContinueOnError bool
+	// Progress is called after every chunk
+	Progress func(progress BulkProgress)
+}
+
+type BulkProgress struct {
+	Chunk      int   // Index of the chunk that completed
+	Chunks     int   // Total number of chunks
+	RowsLoaded int64 // Rows loaded so far by all chunks
+	TotalRows  int
+	Err        error // Error of the chunk, if it failed
+}
+
+// ChunkError is the error of a single chunk of a bulk load.
+// Rows Offset to Offset+Size of the input were not loaded.
+type ChunkError struct {
+	Chunk  int
+	Offset int
+	Size   int
+	Err    error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d (rows %d to %d): %s", e.Chunk, e.Offset, e.Offset+e.Size-1, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+func (d *Database) BulkCreate(objects interface{}, options BulkOptions) (int64, error) {
+	return d.BulkCreateContext(context.Background(), objects, options)
+}
+
+// BulkCreateContext loads a slice of objects in chunks, each committed on its own.
+// It returns the number of rows loaded and the *ChunkError of every failed chunk, joined with errors.Join.
+func (d *Database) BulkCreateContext(ctx context.Context, objects interface{}, options BulkOptions) (int64, error) {
+	slice := reflect.ValueOf(objects)
+	if slice.Kind() != reflect.Slice {
+		return 0, errors.New("BulkCreate expects a slice of objects")
+	}
+	if slice.Len() == 0 {
+		return 0, nil
+	}
+	schema, err := d.getSchema(slice.Index(0).Interface())
+	if err != nil {
+		return 0, err
+	}
+	q := NewQuery(schema, d).WithContext(ctx)
+	if len(options.Omit) > 0 {
+		q.Omit(options.Omit...)
+	}
+	if q.Error() != nil {
+		return 0, q.Error()
+	}
+	fields := q.insertFields()
+	if len(fields) == 0 {
+		return 0, errors.New("BulkCreate has no fields to insert: " + schema.Name)
+	}
+
+	copier, canCopy := d.adapter.(adapter.CopyFromer)
+	wrapped := d.wrappedField(fields)
+	useCopy := options.Method == BulkCopy || (options.Method == BulkAuto && canCopy && wrapped == nil)
+	if useCopy && !canCopy {
+		return 0, fmt.Errorf("%w: adapter does not support COPY", adapter.ErrUnsupported)
+	}
+	if useCopy && wrapped != nil {
+		return 0, fmt.Errorf("%w: COPY cannot write %s through the expression of its custom type", adapter.ErrUnsupported, wrapped.GetFullName())
+	}
+
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		if useCopy {
+			chunkSize = defaultCopyChunkSize
+		} else {
+			chunkSize = d.adapter.Dialect().MaxParams() / len(fields)
+		}
+	}
+	if !useCopy && chunkSize*len(fields) > d.adapter.Dialect().MaxParams() {
+		return 0, fmt.Errorf("chunk size %d exceeds the parameter limit of the dialect", chunkSize)
+	}
+
+	total := slice.Len()
+	chunks := (total + chunkSize - 1) / chunkSize
+	var loaded int64
+	var chunkErrors []error
+	for chunk := 0; chunk < chunks; chunk++ {
+		offset := chunk * chunkSize
+		end := offset + chunkSize
+		if end > total {
+			end = total
+		}
+		var count int64
+		vals, err := model.ParseObject(slice.Slice(offset, end).Interface(), schema)
+		if err == nil {
+			if useCopy {
+				count, err = q.copyValues(copier, fields, vals)
+			} else {
+				count, err = q.insertValues(vals)
+			}
+		}
+		loaded += count
+		if err != nil {
+			err = &ChunkError{Chunk: chunk, Offset: offset, Size: end - offset, Err: err}
+			chunkErrors = append(chunkErrors, err)
+		}
+		if options.Progress != nil {
+			options.Progress(BulkProgress{Chunk: chunk, Chunks: chunks, RowsLoaded: loaded, TotalRows: total, Err: err})
+		}
+		if err != nil && !options.ContinueOnError {
+			break
+		}
+	}
+	return loaded, errors.Join(chunkErrors...)
+}
+
+// insertFields returns the fields written by an insert, in the same order as the compiled statement
+func (q *Query) insertFields() []*model.Field {
+	names := q.mainSchema.AllFieldNames
+	if q.builder.Selections.Size() > 0 {
+		names = q.builder.Selections
+	}
+	keys := names.Difference(q.builder.Omissions).Keys()
+	fields := make([]*model.Field, len(keys))
+	for i, key := range keys {
+		fields[i] = q.GetField(key)
+	}
+	return fields
+}
+
+// wrappedField returns the first field whose custom type writes values through an expression, which COPY cannot apply
+func (d *Database) wrappedField(fields []*model.Field) *model.Field {
+	for _, field := range fields {
+		if field.CustomType == nil {
+			continue
+		}
+		if mapping, ok := field.CustomType.MappingFor(string(d.adapter.Dialect().DatabaseType())); ok && mapping.Write != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+func (q *Query) insertValues(vals []map[string]interface{}) (int64, error) {
+	statement, args, err := q.compileValues(query.InsertQuery, vals)
+	if err != nil {
+		return 0, err
+	}
+	result, err := q.exec(query.InsertQuery, statement, args)
+	return rowsAffected(result, err)
+}
+
+func (q *Query) copyValues(copier adapter.CopyFromer, fields []*model.Field, vals []map[string]interface{}) (count int64, err error) {
+	dialect := q.database.adapter.Dialect()
+	columns := make([]string, len(fields))
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.DBName
+		quoted[i] = dialect.QuoteIdentifier(field.DBName)
+	}
+	rows := make([][]interface{}, len(vals))
+	for i, val := range vals {
+		rows[i] = make([]interface{}, len(fields))
+		for j, field := range fields {
+			rows[i][j] = val[field.Name]
+			if model.IsJSON(field.DataType) {
+				if rows[i][j], err = model.MarshalJSON(val[field.Name]); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	event := q.newEvent(query.InsertQuery, fmt.Sprintf("COPY %s (%s) FROM STDIN", dialect.QuoteIdentifier(q.mainSchema.Table), strings.Join(quoted, ",")), nil)
+	err = q.database.run(q.ctx, event, func(ctx context.Context) (int64, error) {
+		count, err = copier.CopyFrom(ctx, q.mainSchema.Table, columns, rows)
+		return count, err
+	})
+	return
+}
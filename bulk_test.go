@@ -0,0 +1,235 @@
+package atlas
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+type copyingAdapter struct {
+	fakeAdapter
+	columns   []string
+	rows      [][]interface{}
+	calls     int
+	failChunk int
+}
+
+func (c *copyingAdapter) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	c.calls++
+	if c.calls-1 == c.failChunk {
+		return 0, errors.New("copy failed")
+	}
+	c.columns = columns
+	c.rows = append(c.rows, rows...)
+	return int64(len(rows)), nil
+}
+
+func bulkTestCars(count int) []CompileTest {
+	cars := make([]CompileTest, count)
+	for i := range cars {
+		cars[i] = CompileTest{Id: i, Brand: "brand", Location: model.NewLocation(1, 1)}
+	}
+	return cars
+}
+
+func TestBulkCreateChunksInserts(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	progress := make([]BulkProgress, 0)
+	_, err := db.BulkCreate(bulkTestCars(5), BulkOptions{ChunkSize: 2, Progress: func(p BulkProgress) {
+		progress = append(progress, p)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.statements) != 3 || len(progress) != 3 || progress[2].Chunks != 3 || progress[2].TotalRows != 5 {
+		t.Errorf("Expected 3 chunks, got statements %v and progress %+v", fake.statements, progress)
+	}
+
+	if _, err := db.BulkCreate(bulkTestCars(5), BulkOptions{Method: BulkCopy}); err == nil {
+		t.Errorf("Expected COPY to be unsupported by adapter")
+	}
+	if _, err := db.BulkCreate(bulkTestCars(5), BulkOptions{ChunkSize: 30000}); err == nil {
+		t.Errorf("Expected chunk size over the parameter limit to fail")
+	}
+}
+
+func TestBulkCreateCopiesWithChunkErrors(t *testing.T) {
+	copier := &copyingAdapter{failChunk: 1}
+	db := &Database{databaseType: DBType_PostgresPgx, adapter: copier, schemas: make(map[string]model.Schema)}
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := db.BulkCreate(bulkTestCars(7), BulkOptions{ChunkSize: 3, Omit: []string{"Brand"}, ContinueOnError: true})
+	var chunkErr *ChunkError
+	if !errors.As(err, &chunkErr) || chunkErr.Chunk != 1 || chunkErr.Offset != 3 || chunkErr.Size != 3 {
+		t.Fatalf("Expected error for second chunk, got %v", err)
+	}
+	if loaded != 4 || len(copier.rows) != 4 || copier.calls != 3 {
+		t.Errorf("Expected 4 rows loaded in 3 calls, got %d rows in %d calls", loaded, copier.calls)
+	}
+	if len(copier.columns) != 2 || copier.columns[0] != "id" || copier.columns[1] != "location" {
+		t.Errorf("Unexpected columns %v", copier.columns)
+	}
+}
+
+type BulkRoute struct {
+	GeoJSON string
+}
+
+func (r BulkRoute) Value() (driver.Value, error) {
+	return r.GeoJSON, nil
+}
+
+func (r *BulkRoute) Scan(value interface{}) error {
+	r.GeoJSON, _ = value.(string)
+	return nil
+}
+
+type BulkDelivery struct {
+	Id    int `atlas:"primarykey"`
+	Route BulkRoute
+}
+
+func TestBulkCreateInsertsCustomTypesWithWriteExpression(t *testing.T) {
+	err := model.RegisterType(BulkRoute{}, model.CustomType{
+		DataType: "bulk_route",
+		Mapping:  model.ColumnMapping{ColumnType: "geography(linestring)", Write: "ST_GeomFromGeoJSON(%s)::geography"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copier := &copyingAdapter{failChunk: -1}
+	db := &Database{databaseType: DBType_PostgresPgx, adapter: copier, schemas: make(map[string]model.Schema)}
+	if err := db.RegisterModel(BulkDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := []BulkDelivery{{Id: 1, Route: BulkRoute{GeoJSON: "{}"}}}
+	if _, err := db.BulkCreate(deliveries, BulkOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if copier.calls != 0 || len(copier.statements) != 1 {
+		t.Errorf("Expected INSERT instead of COPY, got %d copies and statements %v", copier.calls, copier.statements)
+	}
+	if _, err := db.BulkCreate(deliveries, BulkOptions{Method: BulkCopy}); !errors.Is(err, adapter.ErrUnsupported) {
+		t.Errorf("Expected COPY of custom type with write expression to be unsupported, got %v", err)
+	}
+}
+
+func TestBulkCreateWithoutFields(t *testing.T) {
+	db := newFakeDatabase(&fakeAdapter{})
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.BulkCreate(bulkTestCars(2), BulkOptions{Omit: []string{"Id", "Brand", "Location"}}); err == nil {
+		t.Errorf("Expected error when every field is omitted")
+	}
+}
+
+func TestBulkCreatePointers(t *testing.T) {
+	copier := &copyingAdapter{failChunk: -1}
+	db := &Database{databaseType: DBType_PostgresPgx, adapter: copier, schemas: make(map[string]model.Schema)}
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	cars := bulkTestCars(3)
+	loaded, err := db.BulkCreate([]*CompileTest{&cars[0], &cars[1], &cars[2]}, BulkOptions{})
+	if err != nil || loaded != 3 {
+		t.Fatalf("Expected 3 rows loaded, got %d and %v", loaded, err)
+	}
+	if len(copier.rows) != 3 || copier.rows[2][0] != 2 {
+		t.Errorf("Unexpected rows: %v", copier.rows)
+	}
+
+	fake := &fakeAdapter{}
+	db = newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.BulkCreate([]*CompileTest{&cars[0], &cars[1]}, BulkOptions{}); err != nil || len(fake.statements) != 1 {
+		t.Errorf("Expected pointers to be inserted, got %v and %v", err, fake.statements)
+	}
+	loaded, err = db.BulkCreate([]*CompileTest{&cars[0], nil}, BulkOptions{})
+	var chunkErr *ChunkError
+	if !errors.As(err, &chunkErr) || loaded != 0 {
+		t.Errorf("Expected chunk error for nil object, got %d and %v", loaded, err)
+	}
+}
+
+func TestCreateChunksInTransaction(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	// 3 parameters per row, so 21845 rows fit in a statement
+	result, err := db.Create(bulkTestCars(21846))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 21846*3 {
+		t.Errorf("Expected combined rows affected, got %d", affected)
+	}
+	if len(fake.statements) != 2 || fake.commits != 1 {
+		t.Errorf("Expected 2 statements in 1 transaction, got %d statements and %d commits", len(fake.statements), fake.commits)
+	}
+
+	fake.err = errors.New("boom")
+	if _, err := db.Create(bulkTestCars(21846)); err == nil || fake.rollbacks != 1 {
+		t.Errorf("Expected rollback after failed chunk, got %v", err)
+	}
+
+	fake.err = nil
+	fake.statements = nil
+	if _, err := db.Create(bulkTestCars(21845)); err != nil || len(fake.statements) != 1 || fake.commits != 1 {
+		t.Errorf("Expected single statement without transaction")
+	}
+}
+
+func TestCreateChunksInCallerTransaction(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Transaction(context.Background(), func(tx adapter.Tx) error {
+		_, err := db.Model("CompileTest").WithTx(tx).Create(bulkTestCars(21846))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.statements) != 2 || fake.commits != 1 {
+		t.Errorf("Expected 2 statements in the caller's transaction, got %d statements and %d commits", len(fake.statements), fake.commits)
+	}
+
+	fake.err = errors.New("boom")
+	err = db.Transaction(context.Background(), func(tx adapter.Tx) error {
+		_, err := db.Model("CompileTest").WithTx(tx).Create(bulkTestCars(21846))
+		return err
+	})
+	if err == nil || fake.commits != 1 || fake.rollbacks != 1 {
+		t.Errorf("Expected only the caller's transaction to be rolled back, got %v, %d commits and %d rollbacks", err, fake.commits, fake.rollbacks)
+	}
+}
+
+func BenchmarkCreate100k(b *testing.B) {
+	db := newFakeDatabase(&fakeAdapter{})
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		b.Fatal(err)
+	}
+	cars := bulkTestCars(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.Create(cars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+// goTypes maps the udt_name of a column to the Go type of its field
+var goTypes = map[string]string{
+	"bool":        "bool",
+	"int2":        "int16",
+	"int4":        "int",
+	"int8":        "int64",
+	"float4":      "float32",
+	"float8":      "float64",
+	"numeric":     "string", // float64 would lose precision
+	"varchar":     "string",
+	"bpchar":      "string",
+	"text":        "string",
+	"uuid":        "string",
+	"json":        "map[string]interface{}",
+	"jsonb":       "map[string]interface{}",
+	"bytea":       "[]byte",
+	"timestamp":   "model.Timestamp",
+	"timestamptz": "time.Time",
+}
+
+// defaultStringSize is the length of varchar columns created for string fields without size tag
+const defaultStringSize = 255
+
+// spatialSRID is the spatial reference system of the geography columns atlas reads and writes
+const spatialSRID = 4326
+
+// spatialGoTypes maps the geometry type of geography(4326) columns to the Go type of their field
+var spatialGoTypes = map[string]string{
+	"POINT":   "model.Location",
+	"POLYGON": "model.Region",
+}
+
+// Generate writes a Go source file declaring a model struct for each of the tables.
+// Columns of types atlas cannot map are left out, with a comment in their place.
+// Spatial columns other than geography(4326) points and polygons become string fields holding the raw value,
+// with a TODO comment above them.
+func Generate(w io.Writer, packageName string, tables []Table) error {
+	body := &bytes.Buffer{}
+	usesModel, usesTime := false, false
+	for i, table := range tables {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		name := GoName(table.Name)
+		if model.DefaultDBName(name) != table.Name {
+			fmt.Fprintf(body, "// %s maps table %s, which has to be renamed to %s to match the struct name\n", name, table.Name, model.DefaultDBName(name))
+		} else {
+			fmt.Fprintf(body, "// %s maps table %s\n", name, table.Name)
+		}
+		fmt.Fprintf(body, "type %s struct {\n", name)
+		fieldNames := make(map[string]bool, len(table.Columns))
+		for _, column := range table.Columns {
+			goType, ok := fieldType(column)
+			if !ok {
+				fmt.Fprintf(body, "\t// Column %s of type %s is not supported\n", column.Name, column.UDTName)
+				continue
+			}
+			if isSpatial(column) && !strings.HasPrefix(goType, "model.") {
+				fmt.Fprintf(body, "\t// TODO: column %s is %s, which atlas cannot read as model.Location or model.Region\n", column.Name, spatialColumnType(column))
+			}
+			usesModel = usesModel || strings.HasPrefix(goType, "model.")
+			usesTime = usesTime || goType == "time.Time"
+			if column.Nullable && !column.PrimaryKey && goType != "model.Timestamp" && goType != "[]byte" && !strings.HasPrefix(goType, "map[") {
+				// Pointers hold NULL, model.Timestamp, byte slices and maps do so without
+				goType = "*" + goType
+			}
+			fieldName := GoName(column.Name)
+			for suffix := 2; fieldNames[fieldName]; suffix++ {
+				fieldName = fmt.Sprintf("%s%d", GoName(column.Name), suffix)
+			}
+			fieldNames[fieldName] = true
+			fmt.Fprintf(body, "\t%s %s", fieldName, goType)
+			if tag := fieldTag(fieldName, column); tag != "" {
+				fmt.Fprintf(body, " `atlas:\"%s\"`", tag)
+			}
+			body.WriteString("\n")
+		}
+		body.WriteString("}\n")
+	}
+
+	source := &bytes.Buffer{}
+	source.WriteString("// Code generated by atlas-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(source, "package %s\n\n", packageName)
+	if usesModel || usesTime {
+		source.WriteString("import (\n")
+		if usesTime {
+			source.WriteString("\t\"time\"\n")
+		}
+		if usesTime && usesModel {
+			source.WriteString("\n")
+		}
+		if usesModel {
+			source.WriteString("\t\"github.com/JayPeeTeeDee/atlas/model\"\n")
+		}
+		source.WriteString(")\n\n")
+	}
+	source.Write(body.Bytes())
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+func fieldType(column Column) (string, bool) {
+	if isSpatial(column) {
+		if goType, ok := spatialGoTypes[column.SpatialType]; ok && column.UDTName == "geography" && column.SRID == spatialSRID {
+			return goType, true
+		}
+		return "string", true
+	}
+	goType, ok := goTypes[column.UDTName]
+	return goType, ok
+}
+
+func isSpatial(column Column) bool {
+	return column.UDTName == "geography" || column.UDTName == "geometry"
+}
+
+// spatialColumnType returns the column type of a spatial column, e.g. geometry(LINESTRING, 3857)
+func spatialColumnType(column Column) string {
+	spatialType := column.SpatialType
+	if spatialType == "" {
+		spatialType = "GEOMETRY"
+	}
+	return fmt.Sprintf("%s(%s, %d)", column.UDTName, spatialType, column.SRID)
+}
+
+func fieldTag(fieldName string, column Column) string {
+	settings := make([]string, 0)
+	if column.PrimaryKey {
+		settings = append(settings, "primarykey")
+	}
+	if model.DefaultDBName(fieldName) != column.Name {
+		settings = append(settings, "column:"+column.Name)
+	}
+	switch {
+	case column.UDTName == "text" || (column.UDTName == "varchar" && column.Size == 0):
+		settings = append(settings, "type:text")
+	case column.UDTName == "varchar" && column.Size != defaultStringSize:
+		settings = append(settings, fmt.Sprintf("size:%d", column.Size))
+	case column.UDTName == "json":
+		settings = append(settings, "type:json")
+	}
+	if !column.Nullable && !column.PrimaryKey {
+		settings = append(settings, "not null")
+	}
+	if column.Unique && !column.PrimaryKey {
+		settings = append(settings, "unique")
+	}
+	if column.AutoIncrement {
+		settings = append(settings, "autoincrement")
+	}
+	return strings.Join(settings, ";")
+}
+
+// GoName returns the exported Go identifier for a table or column name, e.g. VehicleType for vehicle_type
+func GoName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	identifier := strings.Builder{}
+	for _, word := range words {
+		runes := []rune(word)
+		identifier.WriteString(strings.ToUpper(string(runes[0])))
+		identifier.WriteString(string(runes[1:]))
+	}
+	goName := identifier.String()
+	if goName == "" || unicode.IsDigit([]rune(goName)[0]) || token.IsKeyword(goName) {
+		goName = "X" + goName
+	}
+	return goName
+}
@@ -0,0 +1,70 @@
+package codegen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tables := []Table{
+		{Name: "vehicle", Columns: []Column{
+			{Name: "id", UDTName: "int4", PrimaryKey: true, AutoIncrement: true},
+			{Name: "plate_number", UDTName: "varchar", Size: 16, Unique: true},
+			{Name: "location", UDTName: "geography", SpatialType: "POINT", SRID: 4326, Nullable: true},
+			{Name: "RegisteredAt", UDTName: "timestamptz", Nullable: true},
+			{Name: "route", UDTName: "geometry", SpatialType: "LINESTRING", SRID: 3857, Nullable: true},
+			{Name: "depot", UDTName: "geometry", SpatialType: "POINT", SRID: 4326},
+			{Name: "mileage", UDTName: "numeric", Nullable: true},
+		}},
+		{Name: "zone", Columns: []Column{
+			{Name: "code", UDTName: "text", PrimaryKey: true},
+			{Name: "area", UDTName: "geography", SpatialType: "POLYGON", SRID: 4326},
+			{Name: "attributes", UDTName: "jsonb", Nullable: true},
+			{Name: "raw_attributes", UDTName: "json", Nullable: true},
+		}},
+	}
+	buffer := &bytes.Buffer{}
+	if err := Generate(buffer, "models", tables); err != nil {
+		t.Fatal(err)
+	}
+	expected := "// Code generated by atlas-gen. DO NOT EDIT.\n\n" +
+		"package models\n\n" +
+		"import (\n\t\"time\"\n\n\t\"github.com/JayPeeTeeDee/atlas/model\"\n)\n\n" +
+		"// Vehicle maps table vehicle\n" +
+		"type Vehicle struct {\n" +
+		"\tId           int    `atlas:\"primarykey;autoincrement\"`\n" +
+		"\tPlateNumber  string `atlas:\"size:16;not null;unique\"`\n" +
+		"\tLocation     *model.Location\n" +
+		"\tRegisteredAt *time.Time `atlas:\"column:RegisteredAt\"`\n" +
+		"\t// TODO: column route is geometry(LINESTRING, 3857), which atlas cannot read as model.Location or model.Region\n" +
+		"\tRoute *string\n" +
+		"\t// TODO: column depot is geometry(POINT, 4326), which atlas cannot read as model.Location or model.Region\n" +
+		"\tDepot   string `atlas:\"not null\"`\n" +
+		"\tMileage *string\n" +
+		"}\n\n" +
+		"// Zone maps table zone\n" +
+		"type Zone struct {\n" +
+		"\tCode          string       `atlas:\"primarykey;type:text\"`\n" +
+		"\tArea          model.Region `atlas:\"not null\"`\n" +
+		"\tAttributes    map[string]interface{}\n" +
+		"\tRawAttributes map[string]interface{} `atlas:\"type:json\"`\n" +
+		"}\n"
+	if buffer.String() != expected {
+		t.Errorf("Unexpected source:\n%s\nexpected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{
+		"vehicle_type": "VehicleType",
+		"UserName":     "UserName",
+		"2d_shape":     "X2dShape",
+		"type":         "Type",
+		"":             "X",
+	}
+	for name, expected := range cases {
+		if goName := GoName(name); goName != expected {
+			t.Errorf("GoName(%q) = %q, expected %q", name, goName, expected)
+		}
+	}
+}
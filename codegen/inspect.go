@@ -0,0 +1,171 @@
+// Package codegen generates atlas model structs from the tables of an existing database.
+package codegen
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/migrate"
+)
+
+// Column is a table column as read from information_schema
+type Column struct {
+	Name          string
+	DataType      string // data_type of information_schema.columns, e.g. integer or USER-DEFINED
+	UDTName       string // Underlying type name, e.g. int4 or geography
+	SpatialType   string // Upper case geometry type of geography and geometry columns, e.g. POINT
+	SRID          int    // Spatial reference system of geography and geometry columns, 0 if unconstrained
+	Size          int    // Maximum length of varchar columns, 0 if unlimited
+	Nullable      bool
+	PrimaryKey    bool
+	Unique        bool // Covered by a single column unique constraint
+	AutoIncrement bool // Serial or identity column
+}
+
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// ignoredTables are left out unless they are asked for explicitly
+var ignoredTables = map[string]bool{
+	"spatial_ref_sys":    true, // Created by PostGIS
+	migrate.DefaultTable: true,
+}
+
+const columnsQuery = `SELECT c.table_name::text, c.column_name::text, c.data_type::text, c.udt_name::text,
+	coalesce(c.character_maximum_length::int, 0),
+	c.is_nullable::text = 'YES', coalesce(c.column_default::text, '') LIKE 'nextval(%' OR c.is_identity::text = 'YES'
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = $1 AND t.table_type = 'BASE TABLE'
+ORDER BY c.table_name, c.ordinal_position`
+
+const constraintsQuery = `SELECT tc.table_name::text, tc.constraint_name::text, tc.constraint_type::text, kcu.column_name::text
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+	ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name AND kcu.table_name = tc.table_name
+WHERE tc.table_schema = $1 AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE')`
+
+const hasPostgisQuery = `SELECT to_regclass('geography_columns') IS NOT NULL AND to_regclass('geometry_columns') IS NOT NULL`
+
+const spatialQuery = `SELECT f_table_name::text, f_geography_column::text, type::text, srid::int FROM geography_columns WHERE f_table_schema = $1
+UNION ALL
+SELECT f_table_name::text, f_geometry_column::text, type::text, srid::int FROM geometry_columns WHERE f_table_schema = $1`
+
+// Inspect reads the tables of the database schema, or only the given tables if any are given
+func Inspect(ctx context.Context, db *atlas.Database, schema string, tables ...string) ([]Table, error) {
+	wanted := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		wanted[table] = true
+	}
+	include := func(table string) bool {
+		if len(wanted) == 0 {
+			return !ignoredTables[table]
+		}
+		return wanted[table]
+	}
+
+	result := make([]Table, 0)
+	err := scanRows(ctx, db, columnsQuery, []interface{}{schema}, func(rows adapter.Rows) error {
+		var table string
+		column := Column{}
+		if err := rows.Scan(&table, &column.Name, &column.DataType, &column.UDTName, &column.Size, &column.Nullable, &column.AutoIncrement); err != nil {
+			return err
+		}
+		if !include(table) {
+			return nil
+		}
+		if len(result) == 0 || result[len(result)-1].Name != table {
+			result = append(result, Table{Name: table})
+		}
+		result[len(result)-1].Columns = append(result[len(result)-1].Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Table, len(result))
+	for i := range result {
+		byName[result[i].Name] = &result[i]
+	}
+	column := func(table string, name string) *Column {
+		if t, ok := byName[table]; ok {
+			for i := range t.Columns {
+				if t.Columns[i].Name == name {
+					return &t.Columns[i]
+				}
+			}
+		}
+		return nil
+	}
+
+	// Unique constraints over several columns cannot be expressed with field tags
+	uniqueColumns := make(map[string][]*Column)
+	err = scanRows(ctx, db, constraintsQuery, []interface{}{schema}, func(rows adapter.Rows) error {
+		var table, constraint, constraintType, name string
+		if err := rows.Scan(&table, &constraint, &constraintType, &name); err != nil {
+			return err
+		}
+		if c := column(table, name); c != nil {
+			if constraintType == "PRIMARY KEY" {
+				c.PrimaryKey = true
+			} else {
+				uniqueColumns[table+"."+constraint] = append(uniqueColumns[table+"."+constraint], c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, columns := range uniqueColumns {
+		if len(columns) == 1 {
+			columns[0].Unique = true
+		}
+	}
+
+	hasPostgis := false
+	err = scanRows(ctx, db, hasPostgisQuery, nil, func(rows adapter.Rows) error {
+		return rows.Scan(&hasPostgis)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasPostgis {
+		return result, nil
+	}
+	err = scanRows(ctx, db, spatialQuery, []interface{}{schema}, func(rows adapter.Rows) error {
+		var table, name, spatialType string
+		var srid int
+		if err := rows.Scan(&table, &name, &spatialType, &srid); err != nil {
+			return err
+		}
+		if c := column(table, name); c != nil {
+			c.SpatialType = strings.ToUpper(spatialType)
+			c.SRID = srid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scanRows runs the query and calls scan for each returned row
+func scanRows(ctx context.Context, db *atlas.Database, query string, args []interface{}, scan func(rows adapter.Rows) error) error {
+	rows, err := db.QueryRowsContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas"
+)
+
+// TestInspect runs against the PostGIS database in ATLAS_TEST_DSN
+func TestInspect(t *testing.T) {
+	dsn := os.Getenv("ATLAS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("ATLAS_TEST_DSN not set")
+	}
+	db, err := atlas.ConnectWithDSN(atlas.DBType_Postgres, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Disconnect()
+
+	seed := []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis",
+		"DROP TABLE IF EXISTS codegen_vehicle",
+		`CREATE TABLE codegen_vehicle (
+			id serial PRIMARY KEY,
+			plate varchar(16) NOT NULL UNIQUE,
+			location geography(point),
+			depot geography(polygon) NOT NULL,
+			route geometry(linestring, 3857)
+		)`,
+	}
+	for _, statement := range seed {
+		if _, err := db.Execute(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer db.Execute("DROP TABLE codegen_vehicle")
+
+	tables, err := Inspect(context.Background(), db, "public", "codegen_vehicle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := &bytes.Buffer{}
+	if err := Generate(buffer, "models", tables); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{
+		"Id       int    `atlas:\"primarykey;autoincrement\"`",
+		"Plate    string `atlas:\"size:16;not null;unique\"`",
+		"Location *model.Location\n",
+		"Depot    model.Region `atlas:\"not null\"`",
+		"// TODO: column route is geometry(LINESTRING, 3857)",
+	} {
+		if !strings.Contains(buffer.String(), field) {
+			t.Errorf("Expected field %q in:\n%s", field, buffer.String())
+		}
+	}
+}
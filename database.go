@@ -1,9 +1,12 @@
 package atlas
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/JayPeeTeeDee/atlas/adapter"
 	"github.com/JayPeeTeeDee/atlas/model"
@@ -15,12 +18,13 @@ type DatabaseType string
 var ErrNoSchema = errors.New("no such schema registered")
 
 const (
-	DBType_Postgres DatabaseType = "Postgres"
+	DBType_Postgres    DatabaseType = "Postgres"
+	DBType_PostgresPgx DatabaseType = "PostgresPgx" // Postgres through a native pgx pool, see adapter.PgxAdapter
 )
 
 func (dt DatabaseType) IsValid() error {
 	switch dt {
-	case DBType_Postgres:
+	case DBType_Postgres, DBType_PostgresPgx:
 		return nil
 	}
 	return errors.New("Invalid database type")
@@ -30,42 +34,191 @@ type Database struct {
 	databaseType DatabaseType
 	adapter      adapter.Adapter
 	schemas      map[string]model.Schema
+	schemaTypes  map[reflect.Type]string // Names of registered schemas by model type, so objects are matched without parsing
+	hooks        []Hook
+	inferNotNull bool // Models are registered with model.Schema.InferNotNull
 }
 
-func ConnectWithDSN(dbType DatabaseType, dsn string) (*Database, error) {
+// NewDatabase creates a database that is not connected yet.
+// It can already be used to register models and compile queries with Query.ToSQL.
+func NewDatabase(dbType DatabaseType) (*Database, error) {
+	if err := dbType.IsValid(); err != nil {
+		return nil, err
+	}
 	var db_adapter adapter.Adapter
 	switch dbType {
 	case DBType_Postgres:
 		db_adapter = &adapter.PostgresAdapter{}
-		err := db_adapter.Connect(dsn)
-		if err != nil {
-			return nil, err
-		}
+	case DBType_PostgresPgx:
+		db_adapter = &adapter.PgxAdapter{}
 	}
 	return &Database{databaseType: dbType, adapter: db_adapter, schemas: make(map[string]model.Schema)}, nil
 }
 
+func ConnectWithDSN(dbType DatabaseType, dsn string) (*Database, error) {
+	db, err := NewDatabase(dbType)
+	if err != nil {
+		return nil, err
+	}
+	err = db.adapter.Connect(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// ConnectWithConfig connects using the connection details and pool settings of config
+func ConnectWithConfig(dbType DatabaseType, config adapter.Config) (*Database, error) {
+	db, err := NewDatabase(dbType)
+	if err != nil {
+		return nil, err
+	}
+	err = db.adapter.ConnectWithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 func (d *Database) Disconnect() error {
 	return d.adapter.Disconnect()
 }
 
+func (d *Database) Dialect() adapter.Dialect {
+	return d.adapter.Dialect()
+}
+
+// PoolStats returns the connection pool statistics of the adapter, if it keeps a pool
+func (d *Database) PoolStats() (sql.DBStats, bool) {
+	provider, ok := d.adapter.(adapter.StatsProvider)
+	if !ok {
+		return sql.DBStats{}, false
+	}
+	return provider.Stats(), true
+}
+
+// StatementCacheStats returns the usage of the adapter's prepared statement cache, if it keeps one
+func (d *Database) StatementCacheStats() (adapter.CacheStats, bool) {
+	cacher, ok := d.adapter.(adapter.StatementCacher)
+	if !ok {
+		return adapter.CacheStats{}, false
+	}
+	return cacher.StatementCacheStats(), true
+}
+
 func (d *Database) CreateTable(schemaName string, ifNotExists bool) error {
+	return d.CreateTableContext(context.Background(), schemaName, ifNotExists)
+}
+
+func (d *Database) CreateTableContext(ctx context.Context, schemaName string, ifNotExists bool) error {
 	schema, ok := d.schemas[schemaName]
 	if !ok {
 		return errors.New("No such schema registered: " + schemaName)
 	}
-	sql := query.CompileTableCreation(NewQuery(schema, d), ifNotExists)
-	_, err := d.Execute(sql)
-	if err == nil {
-		statements := query.CompileIndexCreation(NewQuery(schema, d), ifNotExists)
+	sql, err := query.CompileTableCreation(NewQuery(schema, d), ifNotExists)
+	if err != nil {
+		return err
+	}
+	statements, err := query.CompileIndexCreation(NewQuery(schema, d), ifNotExists)
+	if err != nil {
+		return err
+	}
+	event := &QueryEvent{
+		Operation: OpCreateTable,
+		Model:     schema.Name,
+		Table:     schema.Table,
+		Statement: strings.Join(append([]string{sql}, statements...), "\n"),
+	}
+	return d.run(ctx, event, func(ctx context.Context) (int64, error) {
+		_, err := d.adapter.Exec(ctx, sql)
+		if err != nil {
+			return 0, err
+		}
 		for _, statement := range statements {
-			_, err := d.Execute(statement)
+			_, err := d.adapter.Exec(ctx, statement)
 			if err != nil {
-				return errors.New("Failed to create indexes for: " + schemaName)
+				return 0, errors.New("Failed to create indexes for: " + schemaName)
 			}
 		}
+		return 0, nil
+	})
+}
+
+func (d *Database) DropTable(schemaName string, ifExists bool, cascade bool) error {
+	return d.DropTableContext(context.Background(), schemaName, ifExists, cascade)
+}
+
+// DropTableContext drops the table of the schema together with the indexes created along with it
+func (d *Database) DropTableContext(ctx context.Context, schemaName string, ifExists bool, cascade bool) error {
+	schema, ok := d.schemas[schemaName]
+	if !ok {
+		return errors.New("No such schema registered: " + schemaName)
+	}
+	statements, err := query.CompileTableDrop(NewQuery(schema, d), ifExists, cascade)
+	if err != nil {
+		return err
+	}
+	return d.execTableStatements(ctx, OpDropTable, schema, statements)
+}
+
+func (d *Database) TruncateTable(schemaName string, cascade bool) error {
+	return d.TruncateTableContext(context.Background(), schemaName, cascade)
+}
+
+func (d *Database) TruncateTableContext(ctx context.Context, schemaName string, cascade bool) error {
+	schema, ok := d.schemas[schemaName]
+	if !ok {
+		return errors.New("No such schema registered: " + schemaName)
+	}
+	statement, err := query.CompileTableTruncation(NewQuery(schema, d), cascade)
+	if err != nil {
+		return err
+	}
+	return d.execTableStatements(ctx, OpTruncateTable, schema, []string{statement})
+}
+
+func (d *Database) RenameTable(schemaName string, newTable string) error {
+	return d.RenameTableContext(context.Background(), schemaName, newTable)
+}
+
+// RenameTableContext renames the table of the schema to newTable, along with its idx_<table>_<column> indexes.
+// The schema keeps its table name, so the renamed table is no longer used by the model.
+func (d *Database) RenameTableContext(ctx context.Context, schemaName string, newTable string) error {
+	schema, ok := d.schemas[schemaName]
+	if !ok {
+		return errors.New("No such schema registered: " + schemaName)
+	}
+	statements, err := query.CompileTableRename(NewQuery(schema, d), newTable)
+	if err != nil {
+		return err
+	}
+	return d.execTableStatements(ctx, OpAlterTable, schema, statements)
+}
+
+// execTableStatements runs the statements of a table operation in a single transaction
+func (d *Database) execTableStatements(ctx context.Context, operation Operation, schema model.Schema, statements []string) error {
+	event := &QueryEvent{
+		Operation: operation,
+		Model:     schema.Name,
+		Table:     schema.Table,
+		Statement: strings.Join(statements, "\n"),
 	}
-	return err
+	return d.run(ctx, event, func(ctx context.Context) (int64, error) {
+		return 0, d.Transaction(ctx, func(tx adapter.Tx) error {
+			for _, statement := range statements {
+				if _, err := tx.Exec(ctx, statement); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// InferNotNull sets whether models registered afterwards have NOT NULL columns for the fields that cannot hold NULL.
+// Pointers, sql.Null types, model.Timestamp and byte slices stay nullable.
+func (d *Database) InferNotNull(enabled bool) {
+	d.inferNotNull = enabled
 }
 
 func (d *Database) RegisterModel(target interface{}) error {
@@ -77,17 +230,26 @@ func (d *Database) RegisterModel(target interface{}) error {
 	if err != nil {
 		return err
 	}
+	if d.inferNotNull {
+		schema.InferNotNull()
+	}
 	d.schemas[schema.Name] = *schema
+	if d.schemaTypes == nil {
+		d.schemaTypes = make(map[reflect.Type]string)
+	}
+	d.schemaTypes[schema.ModelType] = schema.Name
 	return nil
 }
 
 func (d *Database) getSchema(target interface{}) (model.Schema, error) {
-	name, err := model.ParseType(target)
+	modelType, err := model.ModelType(target)
 	if err != nil {
 		return model.Schema{}, err
 	}
-
-	return d.GetSchemaByName(name)
+	if name, ok := d.schemaTypes[modelType]; ok {
+		return d.schemas[name], nil
+	}
+	return d.GetSchemaByName(modelType.Name())
 }
 
 func (d *Database) GetSchemaByName(name string) (model.Schema, error) {
@@ -123,9 +285,83 @@ func (d *Database) Update(object interface{}) (sql.Result, error) {
 }
 
 func (d *Database) Execute(query string, args ...interface{}) (sql.Result, error) {
-	return d.adapter.Exec(query, args...)
+	return d.ExecuteContext(context.Background(), query, args...)
+}
+
+func (d *Database) ExecuteContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	event := &QueryEvent{Operation: OpExec, Statement: query, Args: args}
+	err = d.run(ctx, event, func(ctx context.Context) (int64, error) {
+		result, err = d.adapter.Exec(ctx, query, args...)
+		return rowsAffected(result, err)
+	})
+	return
 }
 
 func (d *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return d.adapter.Query(query, args...)
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext runs a raw query through an adapter built on database/sql.
+// Other adapters, such as the pgx adapter, do not return *sql.Rows, use QueryRowsContext with them.
+func (d *Database) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	event := &QueryEvent{Operation: OpQuery, Statement: query, Args: args}
+	err = d.run(ctx, event, func(ctx context.Context) (int64, error) {
+		result, err := d.adapter.Query(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		var ok bool
+		if rows, ok = result.(*sql.Rows); !ok {
+			result.Close()
+			return 0, fmt.Errorf("%w: %T does not return *sql.Rows, use QueryRows", adapter.ErrUnsupported, d.adapter)
+		}
+		return 0, nil
+	})
+	return
+}
+
+func (d *Database) QueryRows(query string, args ...interface{}) (adapter.Rows, error) {
+	return d.QueryRowsContext(context.Background(), query, args...)
+}
+
+// QueryRowsContext runs a raw query through any adapter, returning its rows as adapter.Rows
+func (d *Database) QueryRowsContext(ctx context.Context, query string, args ...interface{}) (rows adapter.Rows, err error) {
+	event := &QueryEvent{Operation: OpQuery, Statement: query, Args: args}
+	err = d.run(ctx, event, func(ctx context.Context) (int64, error) {
+		rows, err = d.adapter.Query(ctx, query, args...)
+		return 0, err
+	})
+	return
+}
+
+// executor runs statements, either directly through the adapter or within a transaction
+type executor interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (adapter.Rows, error)
+}
+
+// Transaction runs fn in a transaction, which is committed if fn succeeds and rolled back otherwise.
+// Queries are run in the transaction with Query.WithTx.
+func (d *Database) Transaction(ctx context.Context, fn func(tx adapter.Tx) error) error {
+	tx, err := d.adapter.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		// Not all drivers report affected rows, this should not fail the statement
+		return 0, nil
+	}
+	return count, nil
 }
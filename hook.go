@@ -0,0 +1,82 @@
+package atlas
+
+import (
+	"context"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+type Operation string
+
+const (
+	OpSelect        Operation = "select"
+	OpCount         Operation = "count"
+	OpInsert        Operation = "insert"
+	OpUpdate        Operation = "update"
+	OpDelete        Operation = "delete"
+	OpCreateTable   Operation = "create_table"
+	OpDropTable     Operation = "drop_table"
+	OpTruncateTable Operation = "truncate_table"
+	OpAlterTable    Operation = "alter_table" // Change to an existing table, e.g. by Database.AutoMigrate or Database.RenameTable
+	OpExec          Operation = "exec"        // Raw statement passed to Database.Execute, or the closing of a cursor of Rows
+	OpQuery         Operation = "query"       // Raw statement passed to Database.Query, or a fetch from a cursor of Rows
+)
+
+func operationOf(kind query.Type) Operation {
+	switch kind {
+	case query.SelectQuery:
+		return OpSelect
+	case query.CountQuery:
+		return OpCount
+	case query.InsertQuery:
+		return OpInsert
+	case query.UpdateQuery:
+		return OpUpdate
+	case query.DeleteQuery:
+		return OpDelete
+	default:
+		return Operation(kind)
+	}
+}
+
+// QueryEvent describes a single statement executed against the database.
+// Duration, RowsAffected and Err are only set once the statement has completed.
+type QueryEvent struct {
+	Operation    Operation
+	Model        string // Name of the registered model, empty for raw statements
+	Table        string
+	Statement    string
+	Args         []interface{}
+	Predicates   []string // Spatial predicates used by the query (see query.SpatialPredicates)
+	StartTime    time.Time
+	Duration     time.Duration
+	RowsAffected int64 // Rows written, or rows scanned for select queries
+	Err          error
+}
+
+// Hook is notified before and after every statement executed through a Database.
+// The context returned by BeforeQuery is passed to the execution and to AfterQuery.
+type Hook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+func (d *Database) AddHook(hook Hook) {
+	d.hooks = append(d.hooks, hook)
+}
+
+// run executes fn with the before and after hooks of the database around it.
+// fn returns the number of rows affected (or scanned) by the statement.
+func (d *Database) run(ctx context.Context, event *QueryEvent, fn func(ctx context.Context) (int64, error)) error {
+	for _, hook := range d.hooks {
+		ctx = hook.BeforeQuery(ctx, event)
+	}
+	event.StartTime = time.Now()
+	event.RowsAffected, event.Err = fn(ctx)
+	event.Duration = time.Since(event.StartTime)
+	for i := len(d.hooks) - 1; i >= 0; i-- {
+		d.hooks[i].AfterQuery(ctx, event)
+	}
+	return event.Err
+}
@@ -0,0 +1,226 @@
+package atlas
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+type fakeAdapter struct {
+	adapter.PostgresAdapter
+	delay      time.Duration
+	err        error
+	statements []string
+	commits    int
+	rollbacks  int
+	results    []*fakeRows // Returned by successive calls to Query
+}
+
+func (f *fakeAdapter) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	time.Sleep(f.delay)
+	f.statements = append(f.statements, query)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (f *fakeAdapter) Query(ctx context.Context, query string, args ...interface{}) (adapter.Rows, error) {
+	f.statements = append(f.statements, query)
+	if len(f.results) == 0 {
+		return nil, errors.New("no results queued")
+	}
+	rows := f.results[0]
+	f.results = f.results[1:]
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]interface{}
+	current int
+	closed  bool
+}
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.current >= len(r.values) {
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, value := range r.values[r.current-1] {
+		target := reflect.ValueOf(dest[i])
+		if scanner, ok := dest[i].(sql.Scanner); ok && (target.Kind() != reflect.Ptr || value == nil || !reflect.TypeOf(value).AssignableTo(target.Type().Elem())) {
+			if err := scanner.Scan(value); err != nil {
+				return err
+			}
+			continue
+		}
+		target.Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (f *fakeAdapter) Begin(ctx context.Context) (adapter.Tx, error) {
+	return fakeTx{f}, nil
+}
+
+type fakeTx struct {
+	*fakeAdapter
+}
+
+func (t fakeTx) Commit() error {
+	t.commits++
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.rollbacks++
+	return nil
+}
+
+type recordingHook struct {
+	before []Operation
+	after  []*QueryEvent
+}
+
+type hookKey struct{}
+
+func (r *recordingHook) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	r.before = append(r.before, event.Operation)
+	return context.WithValue(ctx, hookKey{}, event.Statement)
+}
+
+func (r *recordingHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if ctx.Value(hookKey{}) != event.Statement {
+		panic("context from BeforeQuery not passed to AfterQuery")
+	}
+	r.after = append(r.after, event)
+}
+
+func newFakeDatabase(fake *fakeAdapter) *Database {
+	return &Database{databaseType: DBType_Postgres, adapter: fake, schemas: make(map[string]model.Schema)}
+}
+
+func TestHooksReceiveEvents(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	hook := &recordingHook{}
+	db.AddHook(hook)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := db.Create([]CompileTest{{Id: 1, Brand: "a", Location: model.NewLocation(1, 1)}, {Id: 2, Brand: "b", Location: model.NewLocation(2, 2)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateTable("CompileTest", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hook.after) != 2 || hook.before[0] != OpInsert || hook.before[1] != OpCreateTable {
+		t.Fatalf("Unexpected events: %v", hook.before)
+	}
+	event := hook.after[0]
+	if event.Table != "compile_test" || event.Model != "CompileTest" || event.RowsAffected != 6 || len(event.Args) != 6 || event.Err != nil {
+		t.Errorf("Unexpected insert event: %+v", event)
+	}
+	if event.Statement != fake.statements[0] {
+		t.Errorf("Statement %s does not match executed %s", event.Statement, fake.statements[0])
+	}
+}
+
+func TestHooksReceiveScanErrors(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	hook := &recordingHook{}
+	db.AddHook(hook)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{carRows(1)}
+
+	car := CompileTest{}
+	err := db.Model("CompileTest").Select("Id", "Brand").All(&car)
+	if err == nil {
+		t.Fatal("Expected error for destination that is not a slice")
+	}
+	if len(hook.after) != 1 || hook.after[0].Err != err || hook.after[0].RowsAffected != 0 {
+		t.Errorf("Unexpected events: %+v", hook.after)
+	}
+}
+
+func TestRawQueryRows(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	hook := &recordingHook{}
+	db.AddHook(hook)
+	first, second := carRows(1), carRows(2)
+	fake.results = []*fakeRows{first, second}
+
+	// The fake adapter, like the pgx adapter, does not return *sql.Rows
+	if _, err := db.Query("SELECT id, brand FROM car"); !errors.Is(err, adapter.ErrUnsupported) {
+		t.Errorf("Expected unsupported error, got %v", err)
+	}
+	if !first.closed {
+		t.Errorf("Expected rows to be closed")
+	}
+	rows, err := db.QueryRows("SELECT id, brand FROM car")
+	if err != nil || rows != second {
+		t.Errorf("Expected rows of the adapter, got %v and %v", rows, err)
+	}
+	if len(hook.after) != 2 || hook.after[0].Err == nil || hook.after[1].Operation != OpQuery {
+		t.Errorf("Unexpected events: %+v", hook.after)
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	fake := &fakeAdapter{delay: 5 * time.Millisecond}
+	db := newFakeDatabase(fake)
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	db.AddHook(NewSlogHook(logger, time.Millisecond))
+
+	if _, err := db.Execute("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "level=WARN") || !strings.Contains(buffer.String(), "atlas slow query") {
+		t.Errorf("Expected slow query warning, got %s", buffer.String())
+	}
+
+	buffer.Reset()
+	fake.err = errors.New("boom")
+	if _, err := db.Execute("SELECT 1"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if !strings.Contains(buffer.String(), "level=ERROR") || !strings.Contains(buffer.String(), "error=boom") {
+		t.Errorf("Expected error log, got %s", buffer.String())
+	}
+}
@@ -0,0 +1,52 @@
+package atlas
+
+import (
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+// jsonRows unmarshals the json columns of the queried models into the maps, slices and structs they are scanned into.
+// Drivers only read json columns as text, which they cannot convert to those types.
+type jsonRows struct {
+	adapter.Rows
+	indexes []bool // Positions of the json columns in the result
+}
+
+func (r *jsonRows) Scan(dest ...interface{}) error {
+	for i := range dest {
+		if i >= len(r.indexes) || !r.indexes[i] {
+			continue
+		}
+		if scanner, ok := model.JSONScanner(dest[i]); ok {
+			dest[i] = scanner
+		}
+	}
+	return r.Rows.Scan(dest...)
+}
+
+// jsonColumns returns the positions of the json fields among the selected columns, or nil if there are none.
+// Columns are matched by their field rather than their name, as joined tables may have columns of the same name.
+func (q *Query) jsonColumns() []bool {
+	fields := query.SelectedFields(*q.builder, q)
+	indexes := make([]bool, len(fields))
+	hasJSON := false
+	for i, name := range fields {
+		if field := q.GetField(name); field != nil && model.IsJSON(field.DataType) {
+			indexes[i] = true
+			hasJSON = true
+		}
+	}
+	if !hasJSON {
+		return nil
+	}
+	return indexes
+}
+
+// decodeJSON wraps rows so that json columns are unmarshalled, if the queried models have any
+func decodeJSON(rows adapter.Rows, indexes []bool) adapter.Rows {
+	if indexes == nil {
+		return rows
+	}
+	return &jsonRows{Rows: rows, indexes: indexes}
+}
@@ -0,0 +1,76 @@
+package atlas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+type TelemetrySpec struct {
+	Doors int `json:"doors"`
+}
+
+type Telemetry struct {
+	Id    int `atlas:"primarykey"`
+	Attrs map[string]interface{}
+	Spec  *TelemetrySpec `atlas:"type:jsonb"`
+	Note  string         `atlas:"type:jsonb"`
+}
+
+func TestScanJSONFields(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(Telemetry{}); err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{{
+		columns: []string{"id", "attrs", "spec", "note"},
+		values: [][]interface{}{
+			{1, []byte(`{"fuel_type":"ev"}`), `{"doors":4}`, `"plain"`},
+			{2, nil, nil, `null`},
+		},
+	}}
+
+	var entries []Telemetry
+	if err := db.Model("Telemetry").All(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if !reflect.DeepEqual(entries[0].Attrs, map[string]interface{}{"fuel_type": "ev"}) || entries[0].Spec == nil || entries[0].Spec.Doors != 4 {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[0].Note != `"plain"` {
+		t.Errorf("Expected string fields to be read as JSON text, got %s", entries[0].Note)
+	}
+	if entries[1].Attrs != nil || entries[1].Spec != nil {
+		t.Errorf("Expected NULL json columns to be read as nil, got %+v", entries[1])
+	}
+}
+
+type TelemetryLabel struct {
+	Id          int `atlas:"primarykey"`
+	TelemetryId int
+	Note        string
+}
+
+func TestJSONColumnsOfJoinedModels(t *testing.T) {
+	db := newFakeDatabase(&fakeAdapter{})
+	if err := db.RegisterModel(Telemetry{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RegisterModel(TelemetryLabel{}); err != nil {
+		t.Fatal(err)
+	}
+	q := db.Model("Telemetry").Join("TelemetryLabel", query.Equal{Column: "Telemetry.Id", OtherColumn: "TelemetryLabel.TelemetryId"})
+	// Both tables have a note column, only the one of Telemetry is json
+	expected := []bool{false, true, true, true, false, false, false}
+	if columns := q.jsonColumns(); !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected json columns %v, got %v", expected, columns)
+	}
+	if columns := db.Model("TelemetryLabel").jsonColumns(); columns != nil {
+		t.Errorf("Expected no json columns, got %v", columns)
+	}
+}
@@ -0,0 +1,51 @@
+package atlas
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHook logs executed statements to a slog.Logger.
+// Failed statements are logged at error level and statements taking at least
+// SlowThreshold at warn level, everything else at debug level.
+type SlogHook struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration // Zero disables slow query reporting
+	LogArgs       bool          // Include bound arguments, which may contain sensitive data
+}
+
+func NewSlogHook(logger *slog.Logger, slowThreshold time.Duration) *SlogHook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogHook{Logger: logger, SlowThreshold: slowThreshold}
+}
+
+func (h *SlogHook) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *SlogHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	attrs := []slog.Attr{
+		slog.String("operation", string(event.Operation)),
+		slog.String("table", event.Table),
+		slog.String("statement", event.Statement),
+		slog.Duration("duration", event.Duration),
+		slog.Int64("rows", event.RowsAffected),
+	}
+	if h.LogArgs {
+		attrs = append(attrs, slog.Any("args", event.Args))
+	}
+
+	switch {
+	case event.Err != nil:
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+		h.Logger.LogAttrs(ctx, slog.LevelError, "atlas query failed", attrs...)
+	case h.SlowThreshold > 0 && event.Duration >= h.SlowThreshold:
+		attrs = append(attrs, slog.Duration("threshold", h.SlowThreshold))
+		h.Logger.LogAttrs(ctx, slog.LevelWarn, "atlas slow query", attrs...)
+	default:
+		h.Logger.LogAttrs(ctx, slog.LevelDebug, "atlas query", attrs...)
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"expvar"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ExpvarRegistry publishes metrics as expvar maps keyed by their joined label values
+type ExpvarRegistry struct {
+	prefix string
+}
+
+func NewExpvarRegistry(prefix string) *ExpvarRegistry {
+	return &ExpvarRegistry{prefix: prefix}
+}
+
+func (r *ExpvarRegistry) NewCounter(name string, help string, labelNames ...string) Counter {
+	return &expvarCounter{vals: r.newMap(name)}
+}
+
+func (r *ExpvarRegistry) NewGauge(name string, help string, labelNames ...string) Gauge {
+	return &expvarGauge{vals: r.newMap(name)}
+}
+
+func (r *ExpvarRegistry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram {
+	return &expvarHistogram{vals: r.newMap(name), buckets: buckets}
+}
+
+func (r *ExpvarRegistry) newMap(name string) *expvar.Map {
+	fullName := r.prefix + name
+	if existing, ok := expvar.Get(fullName).(*expvar.Map); ok {
+		return existing
+	}
+	return expvar.NewMap(fullName)
+}
+
+func labelKey(labelValues []string) string {
+	if len(labelValues) == 0 {
+		return "all"
+	}
+	return strings.Join(labelValues, ",")
+}
+
+type expvarCounter struct {
+	vals *expvar.Map
+}
+
+func (c *expvarCounter) Add(value float64, labelValues ...string) {
+	c.vals.AddFloat(labelKey(labelValues), value)
+}
+
+type expvarGauge struct {
+	vals *expvar.Map
+}
+
+func (g *expvarGauge) Set(value float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	if gauge, ok := g.vals.Get(key).(*expvar.Float); ok {
+		gauge.Set(value)
+		return
+	}
+	gauge := new(expvar.Float)
+	gauge.Set(value)
+	g.vals.Set(key, gauge)
+}
+
+type expvarHistogram struct {
+	mutex   sync.Mutex
+	vals    *expvar.Map
+	buckets []float64
+}
+
+// Observe records the value under <labels>:count, <labels>:sum and the cumulative <labels>:le_<bound> buckets
+func (h *expvarHistogram) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	key := labelKey(labelValues)
+	h.vals.AddFloat(key+":count", 1)
+	h.vals.AddFloat(key+":sum", value)
+	for _, bound := range h.buckets {
+		if value <= bound {
+			h.vals.AddFloat(key+":le_"+formatBound(bound), 1)
+		}
+	}
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/georgysavva/scany/dbscan"
+	"github.com/jackc/pgconn"
+)
+
+// Hook records the number, latency, errors and rows of every statement executed by the database it is added to
+type Hook struct {
+	queries  Counter
+	latency  Histogram
+	errors   Counter
+	rows     Counter
+	poolOpen Gauge
+	poolUsed Gauge
+	poolIdle Gauge
+	poolWait Counter
+	db       *atlas.Database
+	lastWait atomic.Int64
+
+	cacheHits   Counter
+	cacheMisses Counter
+	cacheSize   Gauge
+	lastHits    atomic.Uint64
+	lastMisses  atomic.Uint64
+}
+
+func NewHook(registry Registry) *Hook {
+	return &Hook{
+		queries: registry.NewCounter("atlas_queries_total", "Number of statements executed", "operation", "model"),
+		latency: registry.NewHistogram("atlas_query_duration_seconds", "Latency of executed statements", DefaultLatencyBuckets, "operation", "model"),
+		errors:  registry.NewCounter("atlas_query_errors_total", "Number of failed statements", "operation", "model", "class"),
+		rows:    registry.NewCounter("atlas_rows_total", "Rows scanned by select statements or affected by other statements", "operation", "model"),
+	}
+}
+
+// Instrument adds a metrics hook to the database, which also reports the connection pool
+// and prepared statement cache statistics of the database after every statement
+func Instrument(db *atlas.Database, registry Registry) *Hook {
+	hook := NewHook(registry)
+	hook.db = db
+	hook.poolOpen = registry.NewGauge("atlas_pool_open_connections", "Established connections, both in use and idle")
+	hook.poolUsed = registry.NewGauge("atlas_pool_in_use_connections", "Connections currently in use")
+	hook.poolIdle = registry.NewGauge("atlas_pool_idle_connections", "Idle connections")
+	hook.poolWait = registry.NewCounter("atlas_pool_wait_total", "Number of times a statement waited for a connection")
+	hook.cacheHits = registry.NewCounter("atlas_statement_cache_hits_total", "Statements executed with a cached prepared statement")
+	hook.cacheMisses = registry.NewCounter("atlas_statement_cache_misses_total", "Statements prepared because they were not cached")
+	hook.cacheSize = registry.NewGauge("atlas_statement_cache_size", "Prepared statements in the cache")
+	db.AddHook(hook)
+	return hook
+}
+
+func (h *Hook) BeforeQuery(ctx context.Context, event *atlas.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *Hook) AfterQuery(ctx context.Context, event *atlas.QueryEvent) {
+	operation := string(event.Operation)
+	h.queries.Add(1, operation, event.Model)
+	h.latency.Observe(event.Duration.Seconds(), operation, event.Model)
+	h.rows.Add(float64(event.RowsAffected), operation, event.Model)
+	if event.Err != nil {
+		h.errors.Add(1, operation, event.Model, ErrorClass(event.Err))
+	}
+	h.observePool()
+	h.observeStatementCache()
+}
+
+func (h *Hook) observePool() {
+	if h.db == nil {
+		return
+	}
+	stats, ok := h.db.PoolStats()
+	if !ok {
+		return
+	}
+	h.poolOpen.Set(float64(stats.OpenConnections))
+	h.poolUsed.Set(float64(stats.InUse))
+	h.poolIdle.Set(float64(stats.Idle))
+	if previous := h.lastWait.Swap(stats.WaitCount); stats.WaitCount > previous {
+		h.poolWait.Add(float64(stats.WaitCount - previous))
+	}
+}
+
+func (h *Hook) observeStatementCache() {
+	if h.db == nil {
+		return
+	}
+	stats, ok := h.db.StatementCacheStats()
+	if !ok {
+		return
+	}
+	h.cacheSize.Set(float64(stats.Size))
+	if previous := h.lastHits.Swap(stats.Hits); stats.Hits > previous {
+		h.cacheHits.Add(float64(stats.Hits - previous))
+	}
+	if previous := h.lastMisses.Swap(stats.Misses); stats.Misses > previous {
+		h.cacheMisses.Add(float64(stats.Misses - previous))
+	}
+}
+
+// ErrorClass groups errors into a small set of label values.
+// Postgres errors are grouped by the class (first two characters) of their SQLSTATE code, e.g. "sqlstate_23" for integrity constraint violations.
+func ErrorClass(err error) string {
+	var pgErr *pgconn.PgError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, sql.ErrNoRows) || dbscan.NotFound(err):
+		return "not_found"
+	case errors.Is(err, adapter.ErrNotConnected) || errors.As(err, &netErr):
+		return "connection"
+	case errors.Is(err, adapter.ErrUnsupported):
+		return "unsupported"
+	case errors.As(err, &pgErr) && len(pgErr.Code) >= 2:
+		return "sqlstate_" + pgErr.Code[:2]
+	default:
+		return "other"
+	}
+}
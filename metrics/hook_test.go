@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"github.com/jackc/pgconn"
+)
+
+func TestHookRecordsQueries(t *testing.T) {
+	hook := NewHook(NewExpvarRegistry("hooktest_"))
+
+	event := &atlas.QueryEvent{Operation: atlas.OpSelect, Model: "Car", Duration: 20 * time.Millisecond, RowsAffected: 4}
+	hook.AfterQuery(hook.BeforeQuery(context.Background(), event), event)
+	failed := &atlas.QueryEvent{Operation: atlas.OpInsert, Model: "Car", Err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})}
+	hook.AfterQuery(hook.BeforeQuery(context.Background(), failed), failed)
+
+	expected := map[string]string{
+		"hooktest_atlas_queries_total":      `{"insert,Car": 1, "select,Car": 1}`,
+		"hooktest_atlas_rows_total":         `{"insert,Car": 0, "select,Car": 4}`,
+		"hooktest_atlas_query_errors_total": `{"insert,Car,sqlstate_23": 1}`,
+	}
+	for name, value := range expected {
+		if actual := expvar.Get(name).String(); actual != value {
+			t.Errorf("Expected %s to be %s, got %s", name, value, actual)
+		}
+	}
+	latency := expvar.Get("hooktest_atlas_query_duration_seconds").(*expvar.Map)
+	if latency.Get("select,Car:le_0.025").String() != "1" || latency.Get("select,Car:le_0.01") != nil {
+		t.Errorf("Unexpected latency buckets: %s", latency.String())
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	cases := map[error]string{
+		context.DeadlineExceeded:              "timeout",
+		fmt.Errorf("x: %w", context.Canceled): "canceled",
+		&pgconn.PgError{Code: "42P01"}:        "sqlstate_42",
+		fmt.Errorf("boom"):                    "other",
+	}
+	for err, class := range cases {
+		if actual := ErrorClass(err); actual != class {
+			t.Errorf("Expected class %s for %v, got %s", class, err, actual)
+		}
+	}
+}
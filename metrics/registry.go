@@ -0,0 +1,26 @@
+// Package metrics records counters and histograms for statements executed by atlas.
+// Metrics are created through a Registry, so that any metrics backend (e.g. Prometheus or expvar) can be plugged in.
+package metrics
+
+type Counter interface {
+	Add(value float64, labelValues ...string)
+}
+
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+}
+
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+// Registry creates metrics with the given label names.
+// Label values are passed in the same order when the metrics are updated.
+type Registry interface {
+	NewCounter(name string, help string, labelNames ...string) Counter
+	NewGauge(name string, help string, labelNames ...string) Gauge
+	NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the query latency histogram
+var DefaultLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
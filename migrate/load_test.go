@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_brand.up.sql":    {Data: []byte("ALTER TABLE car ADD COLUMN brand varchar(255);\n")},
+		"migrations/0002_add_brand.down.sql":  {Data: []byte("ALTER TABLE car DROP COLUMN brand;")},
+		"migrations/0001_create_car.up.sql":   {Data: []byte("CREATE TABLE car (id int PRIMARY KEY);")},
+		"migrations/0001_create_car.down.sql": {Data: []byte("DROP TABLE car;")},
+		"migrations/README.md":                {Data: []byte("ignored")},
+	}
+	migrations, err := Load(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "add_brand" {
+		t.Fatalf("Unexpected migrations %+v", migrations)
+	}
+	if migrations[1].UpSQL != "ALTER TABLE car ADD COLUMN brand varchar(255);" || migrations[0].DownSQL != "DROP TABLE car;" {
+		t.Errorf("Unexpected statements %+v", migrations)
+	}
+
+	fsys["migrations/0003_missing_up.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err := Load(fsys, "migrations"); err == nil {
+		t.Errorf("Expected error for migration without up file")
+	}
+}
+
+func TestNewRejectsDuplicateVersions(t *testing.T) {
+	if _, err := New(nil, []Migration{{Version: 1, UpSQL: "SELECT 1"}, {Version: 1, UpSQL: "SELECT 2"}}); err == nil {
+		t.Errorf("Expected error for duplicate versions")
+	}
+	migrator, err := New(nil, []Migration{{Version: 2}, {Version: 1}}, WithTable("migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrator.migrations[0].Version != 1 || migrator.table != "migrations" {
+		t.Errorf("Expected migrations sorted by version with custom table")
+	}
+}
@@ -0,0 +1,286 @@
+// Package migrate applies versioned schema migrations to an atlas database.
+// Applied versions are recorded in a bookkeeping table, and every migration runs in its own transaction
+// holding an advisory lock, so that concurrent deploys apply each migration exactly once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"github.com/JayPeeTeeDee/atlas/adapter"
+)
+
+const DefaultTable = "atlas_schema_migrations"
+
+// Executor runs statements within the transaction of a migration
+type Executor interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (adapter.Rows, error)
+}
+
+// Migration is a versioned schema change, given either as Go functions or as SQL.
+// Go functions take precedence over SQL when both are set.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, tx Executor) error
+	Down    func(ctx context.Context, tx Executor) error
+	UpSQL   string
+	DownSQL string
+}
+
+func (m Migration) up(ctx context.Context, tx Executor) error {
+	if m.Up != nil {
+		return m.Up(ctx, tx)
+	}
+	if m.UpSQL == "" {
+		return fmt.Errorf("migration %d has no up step", m.Version)
+	}
+	_, err := tx.Exec(ctx, m.UpSQL)
+	return err
+}
+
+func (m Migration) down(ctx context.Context, tx Executor) error {
+	if m.Down != nil {
+		return m.Down(ctx, tx)
+	}
+	if m.DownSQL == "" {
+		return fmt.Errorf("migration %d has no down step", m.Version)
+	}
+	_, err := tx.Exec(ctx, m.DownSQL)
+	return err
+}
+
+// Status describes a migration known to the migrator or recorded as applied in the database
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // Applied in the database, but not known to the migrator
+}
+
+// database is the part of atlas.Database used by the migrator
+type database interface {
+	Transaction(ctx context.Context, fn func(tx adapter.Tx) error) error
+	Dialect() adapter.Dialect
+}
+
+type Migrator struct {
+	db         database
+	migrations []Migration
+	table      string
+	tableReady bool // The bookkeeping table has been created by this migrator
+}
+
+type Option func(m *Migrator)
+
+// WithTable sets the bookkeeping table, which defaults to DefaultTable
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+func New(db *atlas.Database, migrations []Migration, options ...Option) (*Migrator, error) {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("invalid migration version %d", migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+	}
+	migrator := &Migrator{db: db, migrations: sorted, table: DefaultTable}
+	for _, option := range options {
+		option(migrator)
+	}
+	return migrator, nil
+}
+
+// Up applies all pending migrations in order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo applies the pending migrations up to and including version. A negative version applies all of them.
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	for _, migration := range m.migrations {
+		if version >= 0 && migration.Version > version {
+			break
+		}
+		err := m.locked(ctx, func(tx adapter.Tx, applied map[int64]Status) error {
+			if applied[migration.Version].Applied {
+				return nil
+			}
+			if err := migration.up(ctx, tx); err != nil {
+				return err
+			}
+			dialect := m.db.Dialect()
+			insert := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (%s, %s)", m.quotedTable(), dialect.BindVar(1), dialect.BindVar(2))
+			_, err := tx.Exec(ctx, insert, migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Down rolls back the latest applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	applied := make([]int64, 0)
+	for _, status := range statuses {
+		if status.Applied && !status.Missing {
+			applied = append(applied, status.Version)
+		}
+	}
+	switch len(applied) {
+	case 0:
+		return nil
+	case 1:
+		return m.DownTo(ctx, 0)
+	default:
+		return m.DownTo(ctx, applied[len(applied)-2])
+	}
+}
+
+// DownTo rolls back the applied migrations above version, latest first
+func (m *Migrator) DownTo(ctx context.Context, version int64) error {
+	for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].Version > version; i-- {
+		migration := m.migrations[i]
+		err := m.locked(ctx, func(tx adapter.Tx, applied map[int64]Status) error {
+			if !applied[migration.Version].Applied {
+				return nil
+			}
+			if err := migration.down(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.quotedTable(), m.db.Dialect().BindVar(1)), migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rollback of migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Status returns the known and applied migrations, ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var applied map[int64]Status
+	err := m.locked(ctx, func(tx adapter.Tx, recorded map[int64]Status) error {
+		applied = recorded
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := applied[migration.Version]
+		status.Version = migration.Version
+		status.Name = migration.Name
+		statuses = append(statuses, status)
+		delete(applied, migration.Version)
+	}
+	for _, status := range applied {
+		status.Missing = true
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// locked runs fn in a transaction holding the advisory lock of the bookkeeping table,
+// with the migrations applied at the time the lock was acquired
+func (m *Migrator) locked(ctx context.Context, fn func(tx adapter.Tx, applied map[int64]Status) error) error {
+	if err := m.createTable(ctx); err != nil {
+		return err
+	}
+	return m.db.Transaction(ctx, func(tx adapter.Tx) error {
+		if err := m.lock(ctx, tx); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, applied)
+	})
+}
+
+// createTable creates the bookkeeping table once, in a transaction of its own holding the lock.
+// Keeping the DDL out of the transactions of the migrations means that only migrations changing the schema
+// purge the statement cache.
+func (m *Migrator) createTable(ctx context.Context) error {
+	if m.tableReady {
+		return nil
+	}
+	create, err := m.db.Dialect().CreateMigrationsTable(m.quotedTable())
+	if err != nil {
+		return err
+	}
+	err = m.db.Transaction(ctx, func(tx adapter.Tx) error {
+		if err := m.lock(ctx, tx); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, create)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	m.tableReady = true
+	return nil
+}
+
+// lock takes the advisory lock of the bookkeeping table, held until the transaction ends
+func (m *Migrator) lock(ctx context.Context, tx adapter.Tx) error {
+	statement, err := m.db.Dialect().AdvisoryLock(m.db.Dialect().BindVar(1))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, statement, m.lockKey())
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, tx adapter.Tx) (map[int64]Status, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.quotedTable()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]Status)
+	for rows.Next() {
+		status := Status{Applied: true}
+		if err := rows.Scan(&status.Version, &status.Name, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) quotedTable() string {
+	return m.db.Dialect().QuoteIdentifier(m.table)
+}
+
+// lockKey derives the advisory lock key from the bookkeeping table, so that migrators of different tables do not block each other
+func (m *Migrator) lockKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(m.table))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,256 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+)
+
+// fakeAdapter records the statements of the migrator and keeps the bookkeeping table in memory.
+// Bookkeeping changes of a transaction are only kept if it commits.
+type fakeAdapter struct {
+	statements []string
+	commits    int
+	rollbacks  int
+	failOn     string // Statements containing it fail
+	applied    map[int64]string
+}
+
+func newFakeAdapter() *fakeAdapter {
+	return &fakeAdapter{applied: make(map[int64]string)}
+}
+
+func (f *fakeAdapter) Transaction(ctx context.Context, fn func(tx adapter.Tx) error) error {
+	tx := &fakeTx{fakeAdapter: f, applied: make(map[int64]string)}
+	for version, name := range f.applied {
+		tx.applied[version] = name
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (f *fakeAdapter) Dialect() adapter.Dialect {
+	return adapter.PostgresDialect{}
+}
+
+type fakeTx struct {
+	*fakeAdapter
+	applied map[int64]string
+}
+
+func (t *fakeTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.statements = append(t.statements, query)
+	if t.failOn != "" && strings.Contains(query, t.failOn) {
+		return nil, errors.New("boom")
+	}
+	switch {
+	case strings.HasPrefix(query, `INSERT INTO "atlas_schema_migrations"`):
+		t.applied[args[0].(int64)] = args[1].(string)
+	case strings.HasPrefix(query, `DELETE FROM "atlas_schema_migrations"`):
+		delete(t.applied, args[0].(int64))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (t *fakeTx) Query(ctx context.Context, query string, args ...interface{}) (adapter.Rows, error) {
+	t.statements = append(t.statements, query)
+	rows := &fakeRows{}
+	for version, name := range t.applied {
+		rows.values = append(rows.values, []interface{}{version, name, time.Time{}})
+	}
+	return rows, nil
+}
+
+func (t *fakeTx) Commit() error {
+	t.commits++
+	t.fakeAdapter.applied = t.applied
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	values  [][]interface{}
+	current int
+}
+
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Err() error   { return nil }
+
+func (r *fakeRows) Next() bool {
+	if r.current >= len(r.values) {
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	return []string{"version", "name", "applied_at"}, nil
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, value := range r.values[r.current-1] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func newTestMigrator(t *testing.T, fake *fakeAdapter) *Migrator {
+	migrator, err := New(nil, []Migration{
+		{Version: 2, Name: "add_brand", UpSQL: "ALTER TABLE car ADD COLUMN brand text", DownSQL: "ALTER TABLE car DROP COLUMN brand"},
+		{Version: 1, Name: "create_car", UpSQL: "CREATE TABLE car (id int)", DownSQL: "DROP TABLE car"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator.db = fake
+	return migrator
+}
+
+// createStatements are the statements of the transaction creating the bookkeeping table, run once by a migrator
+var createStatements = []string{
+	"SELECT pg_advisory_xact_lock($1);",
+	`CREATE TABLE IF NOT EXISTS "atlas_schema_migrations" (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamptz NOT NULL DEFAULT now());`,
+}
+
+// lockedStatements are the statements run by every transaction of the migrator before its step
+var lockedStatements = []string{
+	"SELECT pg_advisory_xact_lock($1);",
+	`SELECT version, name, applied_at FROM "atlas_schema_migrations"`,
+}
+
+// expectStatements checks the statements run since the last check: the creation of the bookkeeping table if created is set,
+// followed by a locked transaction for each step
+func expectStatements(t *testing.T, fake *fakeAdapter, created bool, steps ...[]string) {
+	t.Helper()
+	expected := make([]string, 0)
+	if created {
+		expected = append(expected, createStatements...)
+	}
+	for _, step := range steps {
+		expected = append(expected, lockedStatements...)
+		expected = append(expected, step...)
+	}
+	if strings.Join(fake.statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected statements:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(fake.statements, "\n"))
+	}
+	fake.statements = nil
+}
+
+func TestUpAppliesPendingMigrationsInOrder(t *testing.T) {
+	fake := newFakeAdapter()
+	migrator := newTestMigrator(t, fake)
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectStatements(t, fake, true,
+		[]string{"CREATE TABLE car (id int)", `INSERT INTO "atlas_schema_migrations" (version, name) VALUES ($1, $2)`},
+		[]string{"ALTER TABLE car ADD COLUMN brand text", `INSERT INTO "atlas_schema_migrations" (version, name) VALUES ($1, $2)`},
+	)
+	// The bookkeeping table is created in a transaction of its own
+	if fake.commits != 3 || fake.rollbacks != 0 || len(fake.applied) != 2 {
+		t.Errorf("Expected each migration committed on its own, got %d commits, %d rollbacks and %v applied", fake.commits, fake.rollbacks, fake.applied)
+	}
+
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectStatements(t, fake, false, nil, nil)
+}
+
+func TestUpToStopsAtVersion(t *testing.T) {
+	fake := newFakeAdapter()
+	migrator := newTestMigrator(t, fake)
+	if err := migrator.UpTo(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.applied[2]; ok || fake.applied[1] != "create_car" {
+		t.Errorf("Expected only version 1 applied, got %v", fake.applied)
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	fake := newFakeAdapter()
+	fake.failOn = "ADD COLUMN brand"
+	migrator := newTestMigrator(t, fake)
+	err := migrator.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "migration 2 add_brand failed") {
+		t.Fatalf("Expected failure of migration 2, got %v", err)
+	}
+	expectStatements(t, fake, true,
+		[]string{"CREATE TABLE car (id int)", `INSERT INTO "atlas_schema_migrations" (version, name) VALUES ($1, $2)`},
+		[]string{"ALTER TABLE car ADD COLUMN brand text"},
+	)
+	if fake.commits != 2 || fake.rollbacks != 1 {
+		t.Errorf("Expected 2 commits and 1 rollback, got %d and %d", fake.commits, fake.rollbacks)
+	}
+	if _, ok := fake.applied[2]; ok || len(fake.applied) != 1 {
+		t.Errorf("Expected failed migration not to be recorded, got %v", fake.applied)
+	}
+}
+
+func TestDownRollsBackLatestMigration(t *testing.T) {
+	fake := newFakeAdapter()
+	fake.applied = map[int64]string{1: "create_car", 2: "add_brand"}
+	migrator := newTestMigrator(t, fake)
+	if err := migrator.Down(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Status reads the applied migrations before the rollback
+	expectStatements(t, fake, true,
+		nil,
+		[]string{"ALTER TABLE car DROP COLUMN brand", `DELETE FROM "atlas_schema_migrations" WHERE version = $1`},
+	)
+	if len(fake.applied) != 1 || fake.applied[1] != "create_car" {
+		t.Errorf("Expected only version 1 left, got %v", fake.applied)
+	}
+
+	fake.failOn = "DROP TABLE car"
+	if err := migrator.DownTo(context.Background(), 0); err == nil || fake.rollbacks != 1 || len(fake.applied) != 1 {
+		t.Errorf("Expected failed rollback to keep version 1 recorded, got %v and %v", err, fake.applied)
+	}
+	fake.failOn = ""
+	if err := migrator.DownTo(context.Background(), 0); err != nil || len(fake.applied) != 0 {
+		t.Errorf("Expected all migrations rolled back, got %v and %v", err, fake.applied)
+	}
+}
+
+func TestStatusReportsMissingMigrations(t *testing.T) {
+	fake := newFakeAdapter()
+	fake.applied = map[int64]string{1: "create_car", 9: "unknown"}
+	migrator := newTestMigrator(t, fake)
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := make([]string, len(statuses))
+	for i, status := range statuses {
+		summary[i] = fmt.Sprintf("%d %s applied=%t missing=%t", status.Version, status.Name, status.Applied, status.Missing)
+	}
+	expected := []string{
+		"1 create_car applied=true missing=false",
+		"2 add_brand applied=false missing=false",
+		"9 unknown applied=true missing=true",
+	}
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected statuses:\n%s", strings.Join(summary, "\n"))
+	}
+	if fake.commits != 2 {
+		t.Errorf("Expected status to be read in one transaction after creating the table, got %d commits", fake.commits)
+	}
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultIndexPriority is the position of fields in a composite index when no priority is given
+const DefaultIndexPriority = 10
+
+// Index is an index declared with the index or uniqueindex tags of one or more fields.
+// Fields sharing an index name are combined into a composite index, ordered by priority.
+type Index struct {
+	Name    string
+	Unique  bool
+	Type    string   // Index method, e.g. btree or gin, the database default if empty
+	Where   string   // Condition of a partial index
+	Include []string // Database names of columns stored in the index without being indexed
+	Fields  []IndexField
+}
+
+// IndexField is a key of an index, either the column of the field or an expression
+type IndexField struct {
+	Field      *Field
+	Expression string // Indexed instead of the column if set
+	Sort       string // ASC or DESC, the database default if empty
+	Priority   int
+}
+
+// indexOptions are the options of the index and uniqueindex tags, e.g. `atlas:"index:idx_name,priority:2,where:price > 0"`
+var indexOptions = []string{"priority", "type", "where", "expression", "include", "sort"}
+
+// parseIndexTag splits the value of an index tag into the index name and its options.
+// Option values may contain commas, a comma only starts a new option if it is followed by an option name.
+func parseIndexTag(value string) (string, map[string]string) {
+	name := ""
+	options := make(map[string]string)
+	last := ""
+	for i, part := range strings.Split(value, ",") {
+		key, optionValue, isOption := strings.Cut(part, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if isOption && isIndexOption(key) {
+			options[key] = strings.TrimSpace(optionValue)
+			last = key
+		} else if i == 0 {
+			name = strings.TrimSpace(part)
+		} else if last != "" {
+			options[last] += "," + part
+		} else {
+			name += "," + part
+		}
+	}
+	return name, options
+}
+
+// indexTag returns the value of the index or uniqueindex tag of the field.
+// A tag without name but with options, e.g. `atlas:"index,sort:desc"`, is parsed with the first option as part of its key.
+func indexTag(field *Field, tag string) (string, bool) {
+	if value, ok := field.TagSettings[tag]; ok {
+		if value == tag {
+			return "", true
+		}
+		return value, true
+	}
+	for key, value := range field.TagSettings {
+		if option, ok := strings.CutPrefix(key, tag+","); ok {
+			return "," + strings.ToLower(option) + ":" + value, true
+		}
+	}
+	return "", false
+}
+
+func isIndexOption(key string) bool {
+	for _, option := range indexOptions {
+		if key == option {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIndexes collects the indexes declared by the index and uniqueindex tags of the schema fields
+func (schema *Schema) parseIndexes() error {
+	byName := make(map[string]*Index)
+	for _, field := range schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		for _, tag := range []string{"INDEX", "UNIQUEINDEX"} {
+			value, ok := indexTag(field, tag)
+			if !ok {
+				continue
+			}
+			name, options := parseIndexTag(value)
+			if name == "" {
+				name = fmt.Sprintf("idx_%s_%s", schema.Table, field.DBName)
+			}
+			unique := tag == "UNIQUEINDEX"
+
+			index, ok := byName[name]
+			if !ok {
+				index = &Index{Name: name, Unique: unique}
+				byName[name] = index
+				schema.Indexes = append(schema.Indexes, index)
+			} else if index.Unique != unique {
+				return fmt.Errorf("index %s of %s is declared both with index and uniqueindex", name, schema.Name)
+			}
+
+			indexField := IndexField{Field: field, Priority: DefaultIndexPriority}
+			if priority, ok := options["priority"]; ok {
+				value, err := strconv.Atoi(priority)
+				if err != nil {
+					return fmt.Errorf("invalid priority %q of index %s on %s", priority, name, field.GetFullName())
+				}
+				indexField.Priority = value
+			}
+			if sortOrder, ok := options["sort"]; ok {
+				indexField.Sort = strings.ToUpper(sortOrder)
+				if indexField.Sort != "ASC" && indexField.Sort != "DESC" {
+					return fmt.Errorf("invalid sort %q of index %s on %s", sortOrder, name, field.GetFullName())
+				}
+			}
+			indexField.Expression = options["expression"]
+			index.Fields = append(index.Fields, indexField)
+
+			if err := index.mergeOption("type", &index.Type, options); err != nil {
+				return err
+			}
+			if err := index.mergeOption("where", &index.Where, options); err != nil {
+				return err
+			}
+			if include, ok := options["include"]; ok {
+				for _, column := range strings.Split(include, ",") {
+					column = strings.TrimSpace(column)
+					if included, ok := schema.FieldsByName[column]; ok {
+						column = included.DBName
+					}
+					index.Include = append(index.Include, column)
+				}
+			}
+		}
+	}
+	for _, index := range schema.Indexes {
+		sort.SliceStable(index.Fields, func(i, j int) bool {
+			return index.Fields[i].Priority < index.Fields[j].Priority
+		})
+	}
+	return nil
+}
+
+// mergeOption sets an option shared by all fields of the index, which may be given on any one of them
+func (index *Index) mergeOption(key string, target *string, options map[string]string) error {
+	value, ok := options[key]
+	if !ok || value == "" {
+		return nil
+	}
+	if *target != "" && *target != value {
+		return fmt.Errorf("conflicting %s options %q and %q of index %s", key, *target, value, index.Name)
+	}
+	*target = value
+	return nil
+}
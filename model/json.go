@@ -0,0 +1,113 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// isJSONType reports whether values of the type are unmarshalled from json columns: maps and structs
+// that drivers cannot convert themselves, i.e. that implement neither driver.Valuer nor sql.Scanner
+func isJSONType(valueType reflect.Type) bool {
+	switch valueType.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		if valueType == reflect.TypeOf(time.Time{}) {
+			return false
+		}
+		return !valueType.Implements(valuerType) && !reflect.PtrTo(valueType).Implements(scannerType)
+	}
+	return false
+}
+
+// IsJSON reports whether values of the data type are marshalled to JSON when written and unmarshalled when read
+func IsJSON(dataType DataType) bool {
+	return dataType == JSON || dataType == JSONB
+}
+
+// MarshalJSON returns the JSON text of the value of a json field, or nil for nil maps, slices and pointers.
+// Values that are already JSON text, as string, []byte or json.RawMessage, are passed through.
+func MarshalJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case json.RawMessage:
+		if v == nil {
+			return nil, nil
+		}
+		return string(v), nil
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		return string(v), nil
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		if reflected.IsNil() {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// jsonScanner unmarshals a json column into the value target points to
+type jsonScanner struct {
+	target reflect.Value
+}
+
+func (s jsonScanner) Scan(value interface{}) error {
+	// Unmarshalling merges into existing maps, so the previous value is always cleared
+	s.target.Elem().Set(reflect.Zero(s.target.Elem().Type()))
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into json field of type %v", value, s.target.Elem().Type())
+	}
+	return json.Unmarshal(data, s.target.Interface())
+}
+
+// JSONScanner returns a scanner unmarshalling a json column into dest, if dest points to a map, slice or struct.
+// Other destinations, including strings, byte slices and types implementing sql.Scanner, are left to the driver.
+func JSONScanner(dest interface{}) (sql.Scanner, bool) {
+	if dest == nil {
+		return nil, false
+	}
+	if _, ok := dest.(sql.Scanner); ok {
+		return nil, false
+	}
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return nil, false
+	}
+	valueType := target.Type().Elem()
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if _, ok := RegisteredType(valueType); ok {
+		return nil, false
+	}
+	if valueType.Kind() == reflect.Slice && valueType.Elem().Kind() != reflect.Uint8 && !reflect.PtrTo(valueType).Implements(scannerType) {
+		return jsonScanner{target: target}, true
+	}
+	if !isJSONType(valueType) {
+		return nil, false
+	}
+	return jsonScanner{target: target}, true
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type VehicleSpec struct {
+	Doors int    `json:"doors"`
+	Fuel  string `json:"fuel_type"`
+}
+
+func TestSchemaJSONFields(t *testing.T) {
+	type Vehicle struct {
+		Id       int `atlas:"primarykey"`
+		Attrs    map[string]interface{}
+		Spec     VehicleSpec  `atlas:"type:jsonb"`
+		Previous *VehicleSpec `atlas:"type:json"`
+		Untagged VehicleSpec
+		Tags     []string `atlas:"type:jsonb"`
+		Raw      string   `atlas:"type:json"`
+		Ignored  []string
+		Seen     time.Time
+	}
+	schema, err := Parse(Vehicle{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]struct {
+		dataType DataType
+		nullable bool
+	}{
+		"Attrs":    {JSONB, true},
+		"Spec":     {JSONB, false},
+		"Previous": {JSON, true},
+		"Untagged": {"", false},
+		"Tags":     {JSONB, true},
+		"Raw":      {JSON, false},
+		"Ignored":  {"", false},
+		"Seen":     {TimestampTZ, false},
+	}
+	for name, e := range expected {
+		field := schema.FieldsByName[name]
+		if field.DataType != e.dataType || field.Nullable != e.nullable {
+			t.Errorf("Expected %s to be %q (nullable %t), got %q (nullable %t)", name, e.dataType, e.nullable, field.DataType, field.Nullable)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	var nilMap map[string]interface{}
+	var nilSpec *VehicleSpec
+	cases := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{nilMap, nil},
+		{nilSpec, nil},
+		{map[string]interface{}{"fuel_type": "ev"}, `{"fuel_type":"ev"}`},
+		{VehicleSpec{Doors: 4, Fuel: "ev"}, `{"doors":4,"fuel_type":"ev"}`},
+		{`{"raw":true}`, `{"raw":true}`},
+	}
+	for _, c := range cases {
+		value, err := MarshalJSON(c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != c.expected {
+			t.Errorf("MarshalJSON(%#v) = %#v, expected %#v", c.value, value, c.expected)
+		}
+	}
+}
+
+func TestJSONScanner(t *testing.T) {
+	attrs := map[string]interface{}{"stale": true}
+	scanner, ok := JSONScanner(&attrs)
+	if !ok {
+		t.Fatal("Expected a scanner for a map")
+	}
+	if err := scanner.Scan([]byte(`{"fuel_type":"ev"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(attrs, map[string]interface{}{"fuel_type": "ev"}) {
+		t.Errorf("Unexpected attributes: %v", attrs)
+	}
+	if err := scanner.Scan(nil); err != nil || attrs != nil {
+		t.Errorf("Expected nil map for NULL, got %v, %v", attrs, err)
+	}
+
+	spec := &VehicleSpec{}
+	scanner, ok = JSONScanner(&spec)
+	if !ok {
+		t.Fatal("Expected a scanner for a struct pointer")
+	}
+	if err := scanner.Scan(`{"doors":2}`); err != nil || spec == nil || spec.Doors != 2 {
+		t.Errorf("Unexpected spec: %v, %v", spec, err)
+	}
+
+	for _, dest := range []interface{}{new(string), new([]byte), new(Timestamp), new(time.Time), new(Location)} {
+		if _, ok := JSONScanner(dest); ok {
+			t.Errorf("Expected no scanner for %T", dest)
+		}
+	}
+}
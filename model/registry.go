@@ -0,0 +1,109 @@
+package model
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ColumnMapping is how a dialect stores the values of a custom type
+type ColumnMapping struct {
+	ColumnType string // Column type used in table creation, e.g. uuid or numeric(12, 2)
+	Read       string // Expression selecting the value, with %s for the column, e.g. ST_AsGeoJSON(%s). The column itself if empty.
+	Write      string // Expression inserting the value, with %s for the bound parameter, e.g. ST_GeomFromGeoJSON(%s). The parameter itself if empty.
+}
+
+// CustomType describes a user defined field type, see RegisterType
+type CustomType struct {
+	DataType DataType                 // Data type of fields of the type, e.g. uuid
+	Mapping  ColumnMapping            // Used by dialects without an entry in Dialects
+	Dialects map[string]ColumnMapping // Mappings by dialect, keyed by adapter.DbType
+	Nullable bool                     // Values of the type can be NULL, so InferNotNull leaves fields of the type nullable
+}
+
+// MappingFor returns the mapping used by the dialect
+func (t *CustomType) MappingFor(dialect string) (ColumnMapping, bool) {
+	if mapping, ok := t.Dialects[dialect]; ok {
+		return mapping, true
+	}
+	return t.Mapping, t.Mapping.ColumnType != ""
+}
+
+var (
+	typeRegistry     = map[reflect.Type]*CustomType{}
+	typeRegistryLock sync.RWMutex
+
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// RegisterType maps fields of the type of value to columns of the custom type, for models parsed afterwards.
+// Types that are not strings, numbers, booleans or byte slices have to implement driver.Valuer and sql.Scanner
+// (on their pointer), so that drivers can write and read them.
+//
+//	model.RegisterType(uuid.UUID{}, model.CustomType{DataType: "uuid", Mapping: model.ColumnMapping{ColumnType: "uuid"}})
+func RegisterType(value interface{}, customType CustomType) error {
+	if value == nil {
+		return fmt.Errorf("%w: %+v", ErrUnsupportedDataType, value)
+	}
+	valueType := reflect.TypeOf(value)
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if customType.DataType == "" {
+		return fmt.Errorf("no data type for custom type %v", valueType)
+	}
+	if customType.Mapping.ColumnType == "" && len(customType.Dialects) == 0 {
+		return fmt.Errorf("no column type for custom type %v", valueType)
+	}
+	for _, mapping := range append([]ColumnMapping{customType.Mapping}, dialectMappings(customType)...) {
+		for _, format := range []string{mapping.Read, mapping.Write} {
+			if format != "" && strings.Count(format, "%s") != 1 {
+				return fmt.Errorf("expression %q of custom type %v should contain %%s once", format, valueType)
+			}
+		}
+	}
+	if !isDriverKind(valueType) {
+		pointerType := reflect.PtrTo(valueType)
+		if !pointerType.Implements(valuerType) || !pointerType.Implements(scannerType) {
+			return fmt.Errorf("%w: %v does not implement driver.Valuer and sql.Scanner", ErrUnsupportedDataType, valueType)
+		}
+	}
+
+	typeRegistryLock.Lock()
+	defer typeRegistryLock.Unlock()
+	typeRegistry[valueType] = &customType
+	return nil
+}
+
+// RegisteredType returns the custom type registered for the type, if any
+func RegisteredType(valueType reflect.Type) (*CustomType, bool) {
+	typeRegistryLock.RLock()
+	defer typeRegistryLock.RUnlock()
+	customType, ok := typeRegistry[valueType]
+	return customType, ok
+}
+
+func dialectMappings(customType CustomType) []ColumnMapping {
+	mappings := make([]ColumnMapping, 0, len(customType.Dialects))
+	for _, mapping := range customType.Dialects {
+		mappings = append(mappings, mapping)
+	}
+	return mappings
+}
+
+// isDriverKind reports whether drivers convert values of the type by their kind, without driver.Valuer
+func isDriverKind(valueType reflect.Type) bool {
+	switch valueType.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return valueType.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
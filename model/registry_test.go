@@ -0,0 +1,71 @@
+package model
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type testUUID [16]byte
+
+func (u testUUID) Value() (driver.Value, error) {
+	return u[:], nil
+}
+
+func (u *testUUID) Scan(value interface{}) error {
+	copy(u[:], value.([]byte))
+	return nil
+}
+
+type testStatus string
+
+type testUnscannable struct{}
+
+func TestRegisterType(t *testing.T) {
+	err := RegisterType(testUUID{}, CustomType{DataType: "uuid", Mapping: ColumnMapping{ColumnType: "uuid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RegisterType(testStatus(""), CustomType{DataType: "status", Dialects: map[string]ColumnMapping{"POSTGRESQL_DBTYPE": {ColumnType: "trip_status"}}, Nullable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Trip struct {
+		Id     testUUID `atlas:"primarykey"`
+		Status *testStatus
+		Parent testUUID
+	}
+	schema, err := Parse(Trip{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field := schema.FieldsByName["Id"]; field.DataType != "uuid" || field.CustomType == nil || field.DBName != "id" {
+		t.Errorf("Unexpected uuid field: %+v", field)
+	}
+	if field := schema.FieldsByName["Status"]; field.DataType != "status" || !field.Nullable {
+		t.Errorf("Unexpected status field: %+v", field)
+	}
+	if mapping, ok := schema.FieldsByName["Status"].CustomType.MappingFor("POSTGRESQL_DBTYPE"); !ok || mapping.ColumnType != "trip_status" {
+		t.Errorf("Unexpected status mapping: %+v", mapping)
+	}
+	if _, ok := schema.FieldsByName["Status"].CustomType.MappingFor("OTHER"); ok {
+		t.Error("Expected no mapping for dialect without entry")
+	}
+}
+
+func TestRegisterInvalidType(t *testing.T) {
+	cases := map[string]struct {
+		value      interface{}
+		customType CustomType
+	}{
+		"no data type":   {testUUID{}, CustomType{Mapping: ColumnMapping{ColumnType: "uuid"}}},
+		"no column type": {testUUID{}, CustomType{DataType: "uuid"}},
+		"bad read":       {testUUID{}, CustomType{DataType: "uuid", Mapping: ColumnMapping{ColumnType: "uuid", Read: "lower(x)"}}},
+		"not scannable":  {testUnscannable{}, CustomType{DataType: "thing", Mapping: ColumnMapping{ColumnType: "bytea"}}},
+	}
+	for name, c := range cases {
+		if err := RegisterType(c.value, c.customType); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
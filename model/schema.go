@@ -1,11 +1,14 @@
 package model
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"go/ast"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/JayPeeTeeDee/atlas/utils"
 )
@@ -20,11 +23,15 @@ const (
 	Uint          DataType = "uint"
 	Float         DataType = "float"
 	String        DataType = "string"
+	Text          DataType = "text" // String without length limit, set with the type:text tag
 	Time          DataType = "time"
 	Bytes         DataType = "bytes"
 	LocationType  DataType = "location"
 	RegionType    DataType = "region"
 	TimestampType DataType = "timestamp"
+	TimestampTZ   DataType = "timestamptz" // time.Time and sql.NullTime fields
+	JSON          DataType = "json"        // Set with the type:json tag, stored as JSON text
+	JSONB         DataType = "jsonb"       // Maps, and structs and slices with the type:jsonb tag
 )
 
 type Schema struct {
@@ -37,10 +44,11 @@ type Schema struct {
 	FieldsByName   map[string]*Field
 	FieldsByDBName map[string]*Field
 
-	AllFieldNames      *utils.Set
-	PrimaryFieldNames  *utils.Set // Used for convenience
-	LocationFieldNames *utils.Set
-	RegionFieldNames   *utils.Set
+	AllFieldNames      *utils.OrderedSet
+	PrimaryFieldNames  *utils.OrderedSet // Used for convenience
+	LocationFieldNames *utils.OrderedSet
+	RegionFieldNames   *utils.OrderedSet
+	Indexes            []*Index // Indexes declared with field tags, in order of declaration
 }
 
 type Field struct {
@@ -50,14 +58,18 @@ type Field struct {
 	IndirectFieldType reflect.Type
 	DataType          DataType
 	StructField       reflect.StructField
+	Index             []int // Index path of the field in the model struct, for reflect.Value.FieldByIndex
 	Tag               reflect.StructTag
 	TagSettings       map[string]string
 	PrimaryKey        bool
 	AutoIncrement     bool
 	NotNull           bool
+	Nullable          bool // Field can hold NULL: pointers, sql.Null types, model.Timestamp, byte slices, maps and json slices
 	Unique            bool
+	Size              int // Maximum length of string fields, set with the size tag. 0 uses the default of the dialect.
 	HasDefaultValue   bool
 	DefaultValue      interface{}
+	CustomType        *CustomType // Set for fields of types registered with RegisterType
 	Schema            *Schema
 }
 
@@ -82,18 +94,41 @@ func (schema Schema) SetDefaultValues(target interface{}) error {
 	return nil
 }
 
+// nullTypes maps the nullable types of database/sql to the data type of their value
+var nullTypes = map[reflect.Type]DataType{
+	reflect.TypeOf(sql.NullBool{}):    Bool,
+	reflect.TypeOf(sql.NullByte{}):    Uint,
+	reflect.TypeOf(sql.NullInt16{}):   Int,
+	reflect.TypeOf(sql.NullInt32{}):   Int,
+	reflect.TypeOf(sql.NullInt64{}):   Int,
+	reflect.TypeOf(sql.NullFloat64{}): Float,
+	reflect.TypeOf(sql.NullString{}):  String,
+	reflect.TypeOf(sql.NullTime{}):    TimestampTZ,
+}
+
+// InferNotNull makes the fields that cannot hold NULL (see Field.Nullable) NOT NULL, unless they have a default value
+func (schema *Schema) InferNotNull() {
+	for _, field := range schema.Fields {
+		if !field.Nullable && !field.HasDefaultValue && field.DataType != "" {
+			field.NotNull = true
+		}
+	}
+}
+
 func (schema *Schema) ParseField(fieldStruct reflect.StructField) *Field {
 	field := &Field{
 		Name:              fieldStruct.Name,
 		FieldType:         fieldStruct.Type,
 		IndirectFieldType: fieldStruct.Type,
 		StructField:       fieldStruct,
+		Index:             fieldStruct.Index,
 		Tag:               fieldStruct.Tag,
 		TagSettings:       parseTagSetting(fieldStruct.Tag.Get("atlas"), ";"),
 		Schema:            schema,
 	}
 	for field.IndirectFieldType.Kind() == reflect.Ptr {
 		field.IndirectFieldType = field.IndirectFieldType.Elem()
+		field.Nullable = true
 	}
 
 	fieldValue := reflect.New(field.IndirectFieldType)
@@ -122,6 +157,32 @@ func (schema *Schema) ParseField(fieldStruct reflect.StructField) *Field {
 		field.HasDefaultValue = true
 	}
 
+	if customType, ok := RegisteredType(field.IndirectFieldType); ok {
+		field.CustomType = customType
+		field.DataType = customType.DataType
+		field.Nullable = field.Nullable || customType.Nullable
+	} else {
+		field.parseDataType(fieldValue)
+	}
+
+	if val, ok := field.TagSettings["TYPE"]; ok {
+		switch DataType(strings.ToLower(val)) {
+		case Bool, Int, Uint, Float, String, Text, Time, Bytes, TimestampTZ, JSON, JSONB:
+			field.DataType = DataType(strings.ToLower(val))
+		default:
+			// Rejected by checkType, unless it is the data type of the registered type of the field
+			field.DataType = DataType(val)
+		}
+		if IsJSON(field.DataType) && field.IndirectFieldType.Kind() == reflect.Slice {
+			field.Nullable = true
+		}
+	}
+
+	return field
+}
+
+// parseDataType sets the data type of the field from the kind of its (dereferenced) type
+func (field *Field) parseDataType(fieldValue reflect.Value) {
 	switch reflect.Indirect(fieldValue).Kind() {
 	case reflect.Bool:
 		field.DataType = Bool
@@ -134,29 +195,63 @@ func (schema *Schema) ParseField(fieldStruct reflect.StructField) *Field {
 	case reflect.String:
 		field.DataType = String
 	case reflect.Struct:
-		if IsLocation(fieldValue) {
+		if dataType, ok := nullTypes[field.IndirectFieldType]; ok {
+			field.DataType = dataType
+			field.Nullable = true
+		} else if field.IndirectFieldType == reflect.TypeOf(time.Time{}) {
+			field.DataType = TimestampTZ
+		} else if IsLocation(fieldValue) {
 			field.DataType = LocationType
 		} else if IsRegion(fieldValue) {
 			field.DataType = RegionType
 		} else if IsTimestamp(fieldValue) {
 			field.DataType = TimestampType
+			field.Nullable = true
 		}
+	case reflect.Map:
+		field.DataType = JSONB
+		field.Nullable = true
 	case reflect.Array, reflect.Slice:
 		if reflect.Indirect(fieldValue).Type().Elem() == reflect.TypeOf(uint8(0)) {
 			field.DataType = Bytes
+			field.Nullable = field.Nullable || reflect.Indirect(fieldValue).Kind() == reflect.Slice
 		}
 	}
+}
 
-	if val, ok := field.TagSettings["TYPE"]; ok {
-		switch DataType(strings.ToLower(val)) {
-		case Bool, Int, Uint, Float, String, Time, Bytes:
-			field.DataType = DataType(strings.ToLower(val))
-		default:
-			field.DataType = DataType(val)
-		}
+// checkType checks that the type tag of the field names a known data type, or the data type of its registered type
+func (field *Field) checkType() error {
+	switch field.DataType {
+	case "", Bool, Int, Uint, Float, String, Text, Time, Bytes, LocationType, RegionType, TimestampType, TimestampTZ, JSON, JSONB:
+		return nil
 	}
+	if field.CustomType != nil && field.DataType == field.CustomType.DataType {
+		return nil
+	}
+	return fmt.Errorf("%w: unknown type %q of %s, custom types have to be registered with model.RegisterType",
+		ErrUnsupportedDataType, field.DataType, field.GetFullName())
+}
 
-	return field
+// parseSize sets the size of string fields from the size tag, and checks that text and size are only used for strings.
+// Nullable strings, i.e. sql.NullString, count as strings.
+func (field *Field) parseSize() error {
+	isString := field.IndirectFieldType.Kind() == reflect.String || nullTypes[field.IndirectFieldType] == String
+	if field.DataType == Text && !isString {
+		return fmt.Errorf("%w: type text of %s requires a string field", ErrUnsupportedDataType, field.GetFullName())
+	}
+	value, ok := field.TagSettings["SIZE"]
+	if !ok {
+		return nil
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || size <= 0 {
+		return fmt.Errorf("invalid size %q of %s", value, field.GetFullName())
+	}
+	if !isString || field.DataType != String {
+		return fmt.Errorf("%w: size of %s requires a string field without type", ErrUnsupportedDataType, field.GetFullName())
+	}
+	field.Size = size
+	return nil
 }
 
 func Parse(target interface{}) (*Schema, error) {
@@ -164,9 +259,9 @@ func Parse(target interface{}) (*Schema, error) {
 		return nil, fmt.Errorf("%w: %+v", ErrUnsupportedDataType, target)
 	}
 
-	modelType := reflect.ValueOf(target).Type()
-	for modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array || modelType.Kind() == reflect.Ptr {
-		modelType = modelType.Elem()
+	modelType, err := ModelType(target)
+	if err != nil {
+		return nil, err
 	}
 
 	if modelType.Kind() != reflect.Struct {
@@ -183,10 +278,10 @@ func Parse(target interface{}) (*Schema, error) {
 		FieldsByName:       map[string]*Field{},
 		FieldsByDBName:     map[string]*Field{},
 		PrimaryFields:      make([]*Field, 0),
-		AllFieldNames:      utils.NewSet(),
-		PrimaryFieldNames:  utils.NewSet(),
-		LocationFieldNames: utils.NewSet(),
-		RegionFieldNames:   utils.NewSet(),
+		AllFieldNames:      utils.NewOrderedSet(),
+		PrimaryFieldNames:  utils.NewOrderedSet(),
+		LocationFieldNames: utils.NewOrderedSet(),
+		RegionFieldNames:   utils.NewOrderedSet(),
 	}
 
 	for i := 0; i < modelType.NumField(); i++ {
@@ -223,30 +318,60 @@ func Parse(target interface{}) (*Schema, error) {
 		}
 	}
 
+	for _, field := range schema.Fields {
+		if err := field.checkType(); err != nil {
+			return nil, err
+		}
+		if err := field.parseSize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := schema.parseIndexes(); err != nil {
+		return nil, err
+	}
+
 	return schema, nil
 }
 
 func ParseType(target interface{}) (string, error) {
+	modelType, err := ModelType(target)
+	if err != nil {
+		return "", err
+	}
+	return modelType.Name(), nil
+}
+
+// ModelType returns the struct type of the target, dereferencing pointers, slices and arrays
+func ModelType(target interface{}) (reflect.Type, error) {
 	if target == nil {
-		return "", fmt.Errorf("%w: %+v", ErrUnsupportedDataType, target)
+		return nil, fmt.Errorf("%w: %+v", ErrUnsupportedDataType, target)
 	}
 	modelType := reflect.ValueOf(target).Type()
 	for modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array || modelType.Kind() == reflect.Ptr {
 		modelType = modelType.Elem()
 	}
-	return modelType.Name(), nil
+	return modelType, nil
 }
 
+// ParseObject returns the values of the fields of the object, or of each object of a slice.
+// Slices may hold pointers to objects, which must not be nil.
 func ParseObject(target interface{}, schema Schema) ([]map[string]interface{}, error) {
 	targetItem := reflect.ValueOf(target)
-	res := make([]map[string]interface{}, 0)
+	var res []map[string]interface{}
 	if targetItem.Kind() == reflect.Slice {
-		for i := 0; i < targetItem.Len(); i++ {
-			item := targetItem.Index(i)
-			res = append(res, parseStruct(item, schema))
+		res = make([]map[string]interface{}, targetItem.Len())
+		for i := range res {
+			item := reflect.Indirect(targetItem.Index(i))
+			if !item.IsValid() {
+				return nil, fmt.Errorf("nil object at index %d", i)
+			}
+			res[i] = parseStruct(item, schema)
 		}
 	} else if targetItem.Kind() == reflect.Struct {
-		res = append(res, parseStruct(targetItem, schema))
+		res = []map[string]interface{}{parseStruct(targetItem, schema)}
+	} else {
+		res = make([]map[string]interface{}, 0)
 	}
 
 	return res, nil
@@ -264,9 +389,9 @@ func ParseSingleObject(target interface{}, schema Schema) (map[string]interface{
 }
 
 func parseStruct(targetValue reflect.Value, schema Schema) map[string]interface{} {
-	itemRes := make(map[string]interface{})
+	itemRes := make(map[string]interface{}, len(schema.Fields))
 	for _, field := range schema.Fields {
-		itemRes[field.Name] = targetValue.FieldByName(field.Name).Interface()
+		itemRes[field.Name] = targetValue.FieldByIndex(field.Index).Interface()
 	}
 	return itemRes
 }
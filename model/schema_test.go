@@ -1,7 +1,9 @@
 package model
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 )
 
 type TestStruct struct {
@@ -20,3 +22,170 @@ func TestSchema(t *testing.T) {
 	t.Log(schema.Fields[1].DataType)
 	t.Log(schema.Table)
 }
+
+type IndexStruct struct {
+	Id        int    `atlas:"primarykey"`
+	Brand     string `atlas:"index"`
+	TenantId  int    `atlas:"index:idx_tenant_created,priority:2"`
+	CreatedAt int    `atlas:"index:idx_tenant_created,priority:1,sort:desc,include:Brand,Plate"`
+	Plate     string `atlas:"uniqueindex:idx_plate,expression:lower(plate),where:plate IN ('a', 'b')"`
+}
+
+func TestSchemaIndexes(t *testing.T) {
+	schema, err := Parse(IndexStruct{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Indexes) != 3 {
+		t.Fatalf("Expected 3 indexes, got %d", len(schema.Indexes))
+	}
+	brand, composite, plate := schema.Indexes[0], schema.Indexes[1], schema.Indexes[2]
+	if brand.Name != "idx_index_struct_brand" || brand.Unique || len(brand.Fields) != 1 {
+		t.Errorf("Unexpected default index: %+v", brand)
+	}
+	if composite.Name != "idx_tenant_created" || composite.Fields[0].Field.Name != "CreatedAt" || composite.Fields[0].Sort != "DESC" || composite.Fields[1].Field.Name != "TenantId" {
+		t.Errorf("Unexpected composite index: %+v", composite)
+	}
+	if len(composite.Include) != 2 || composite.Include[0] != "brand" || composite.Include[1] != "plate" {
+		t.Errorf("Unexpected included columns: %v", composite.Include)
+	}
+	if !plate.Unique || plate.Fields[0].Expression != "lower(plate)" || plate.Where != "plate IN ('a', 'b')" {
+		t.Errorf("Unexpected expression index: %+v", plate)
+	}
+
+	type ConflictStruct struct {
+		A int `atlas:"index:idx_conflict"`
+		B int `atlas:"uniqueindex:idx_conflict"`
+	}
+	if _, err := Parse(ConflictStruct{}); err == nil {
+		t.Error("Expected error for index declared both unique and not unique")
+	}
+}
+
+func TestSchemaStringSizes(t *testing.T) {
+	type Address struct {
+		Id      int            `atlas:"primarykey"`
+		Street  string         `atlas:"size:1000"`
+		Comment string         `atlas:"type:TEXT"`
+		Note    sql.NullString `atlas:"size:500"`
+	}
+	schema, err := Parse(Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.FieldsByName["Street"].Size != 1000 || schema.FieldsByName["Street"].DataType != String {
+		t.Errorf("Unexpected street field: %+v", schema.FieldsByName["Street"])
+	}
+	if schema.FieldsByName["Comment"].DataType != Text {
+		t.Errorf("Expected text comment, got %s", schema.FieldsByName["Comment"].DataType)
+	}
+	if schema.FieldsByName["Note"].Size != 500 || !schema.FieldsByName["Note"].Nullable {
+		t.Errorf("Unexpected note field: %+v", schema.FieldsByName["Note"])
+	}
+
+	type InvalidSize struct {
+		Street string `atlas:"size:long"`
+	}
+	type SizedInt struct {
+		Count int `atlas:"size:10"`
+	}
+	type TextInt struct {
+		Count int `atlas:"type:text"`
+	}
+	type UnknownType struct {
+		Count int `atlas:"type:numeric(10, 2)"`
+	}
+	for _, target := range []interface{}{InvalidSize{}, SizedInt{}, TextInt{}, UnknownType{}} {
+		if _, err := Parse(target); err == nil {
+			t.Errorf("Expected error parsing %T", target)
+		}
+	}
+}
+
+func TestSchemaNullableFields(t *testing.T) {
+	type Trip struct {
+		Id        int `atlas:"primarykey"`
+		StartedAt time.Time
+		EndedAt   *time.Time
+		Note      sql.NullString
+		Driver    *string
+		Fare      float64
+		Recorded  Timestamp
+		Route     []byte
+		Origin    Location `atlas:"default"`
+	}
+	schema, err := Parse(Trip{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]struct {
+		dataType DataType
+		nullable bool
+	}{
+		"StartedAt": {TimestampTZ, false},
+		"EndedAt":   {TimestampTZ, true},
+		"Note":      {String, true},
+		"Driver":    {String, true},
+		"Fare":      {Float, false},
+		"Recorded":  {TimestampType, true},
+		"Route":     {Bytes, true},
+	}
+	for name, e := range expected {
+		field := schema.FieldsByName[name]
+		if field.DataType != e.dataType || field.Nullable != e.nullable {
+			t.Errorf("Expected %s to be %s (nullable %t), got %s (nullable %t)", name, e.dataType, e.nullable, field.DataType, field.Nullable)
+		}
+	}
+
+	schema.InferNotNull()
+	for _, name := range []string{"StartedAt", "Fare"} {
+		if !schema.FieldsByName[name].NotNull {
+			t.Errorf("Expected %s to be inferred not null", name)
+		}
+	}
+	for _, name := range []string{"EndedAt", "Note", "Driver", "Recorded", "Route", "Origin"} {
+		if schema.FieldsByName[name].NotNull {
+			t.Errorf("Expected %s to stay nullable", name)
+		}
+	}
+}
+
+func TestTimestampValue(t *testing.T) {
+	value, err := Timestamp{}.Value()
+	if err != nil || value != nil {
+		t.Errorf("Expected NULL for zero timestamp, got %v, %v", value, err)
+	}
+	timestamp := Timestamp{}
+	if err := timestamp.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if value, _ := NewTimestamp(now).Value(); value != now {
+		t.Errorf("Expected %v, got %v", now, value)
+	}
+}
+
+type BenchStruct struct {
+	Id       int `atlas:"primarykey"`
+	Brand    string
+	Model    string
+	Mileage  float64
+	Location Location
+}
+
+func BenchmarkParseObject100k(b *testing.B) {
+	schema, err := Parse(BenchStruct{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows := make([]BenchStruct, 100000)
+	for i := range rows {
+		rows[i] = BenchStruct{Id: i, Brand: "brand", Model: "model", Location: NewLocation(1, 1)}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseObject(rows, *schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
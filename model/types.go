@@ -153,6 +153,9 @@ type Timestamp struct {
 
 func (t *Timestamp) Scan(value interface{}) error {
 	switch v := value.(type) {
+	case nil:
+		t.time = nil
+		return nil
 	case time.Time:
 		t.time = &v
 		return nil
@@ -161,7 +164,11 @@ func (t *Timestamp) Scan(value interface{}) error {
 	}
 }
 
+// Value returns NULL for a Timestamp without time, e.g. the zero Timestamp
 func (t Timestamp) Value() (driver.Value, error) {
+	if t.time == nil {
+		return nil, nil
+	}
 	return *(t.time), nil
 }
 
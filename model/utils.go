@@ -67,6 +67,11 @@ var (
 	matchAllCapRe   = regexp.MustCompile("([a-z0-9])([A-Z])")
 )
 
+// DefaultDBName returns the table name of a model, or the column name of a field without a column tag
+func DefaultDBName(name string) string {
+	return toSnakeCase(name)
+}
+
 func toSnakeCase(str string) string {
 	snake := matchFirstCapRe.ReplaceAllString(str, "${1}_${2}")
 	snake = matchAllCapRe.ReplaceAllString(snake, "${1}_${2}")
@@ -0,0 +1,186 @@
+package atlas
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// pageCursor holds the values of the order keys for the last entry of a page
+type pageCursor struct {
+	Keys []json.RawMessage `json:"k"`
+}
+
+// After makes Page return the entries following the entry the cursor was returned for.
+// The query must have the same orders as the query that returned the cursor.
+func (q *Query) After(cursor string) *Query {
+	q.after = cursor
+	return q
+}
+
+// Page populates response with up to size entries, seeking past the entry of the cursor set with After rather than using an offset.
+// Entries are ordered by the orders of the query followed by the primary key, which breaks ties.
+// It returns the cursor of the last entry, or an empty cursor if there are no more entries.
+func (q *Query) Page(size uint64, response interface{}) (string, error) {
+	if q.Error() != nil {
+		return "", q.Error()
+	}
+	orders := q.pageOrders()
+	if len(orders) == len(q.builder.Orders) && len(q.mainSchema.PrimaryFields) == 0 {
+		return "", errors.New("Keyset pagination requires a primary key: " + q.mainSchema.Name)
+	}
+	keys := make([]query.SeekKey, len(orders))
+	fields := make([]*model.Field, len(orders))
+	for i, order := range orders {
+		keys[i].Order = order
+		switch order := order.(type) {
+		case query.ColumnOrder:
+			fields[i] = q.GetField(order.Column)
+		case query.SpatialOrder:
+			if order.TargetColumn != "" {
+				return "", errors.New("Keyset pagination is not supported for distances between columns")
+			}
+			fields[i] = q.GetField(order.Column)
+		default:
+			return "", fmt.Errorf("Keyset pagination is not supported for order %T", order)
+		}
+		if fields[i] == nil || fields[i].Schema.Name != q.mainSchema.Name {
+			return "", errors.New("Keyset pagination only supports orders on fields of " + q.mainSchema.Name)
+		}
+		// Comparisons with NULL are never true, so entries with NULL keys would be skipped by the seek.
+		// Fields that cannot hold NULL fail to scan NULL, so only nullable fields have to be NOT NULL.
+		if fields[i].Nullable && !fields[i].NotNull && !fields[i].PrimaryKey {
+			return "", errors.New("Keyset pagination requires order keys that cannot be NULL: " + fields[i].GetFullName())
+		}
+		// The cursor is read from the entries, so every key has to be selected
+		name := fields[i].GetFullName()
+		if (q.builder.Selections.Size() > 0 && !q.builder.Selections.Contains(name)) || q.builder.Omissions.Contains(name) {
+			return "", errors.New("Keyset pagination requires the order key to be selected: " + name)
+		}
+	}
+
+	builder := *q.builder
+	builder.Orders = orders
+	builder.Limit = size
+	if q.after != "" {
+		values, err := decodeCursor(q.after, fields)
+		if err != nil {
+			return "", err
+		}
+		for i := range keys {
+			keys[i].Value = values[i]
+		}
+		builder.Clauses = append(append([]query.Clause{}, builder.Clauses...), query.Seek{Keys: keys})
+	}
+	pageQuery := *q
+	pageQuery.builder = &builder
+	if err := pageQuery.All(response); err != nil {
+		return "", err
+	}
+
+	entries := reflect.Indirect(reflect.ValueOf(response))
+	if uint64(entries.Len()) < size || entries.Len() == 0 {
+		return "", nil
+	}
+	return encodeCursor(reflect.Indirect(entries.Index(entries.Len()-1)), fields)
+}
+
+// pageOrders returns the orders of the query followed by the primary key fields it is not ordered by yet
+func (q *Query) pageOrders() []query.Order {
+	orders := append([]query.Order{}, q.builder.Orders...)
+	for _, field := range q.mainSchema.PrimaryFields {
+		ordered := false
+		for _, order := range q.builder.Orders {
+			if column, ok := order.(query.ColumnOrder); ok && q.GetField(column.Column) == field {
+				ordered = true
+			}
+		}
+		if !ordered {
+			orders = append(orders, query.ColumnOrder{Column: field.Name})
+		}
+	}
+	return orders
+}
+
+func encodeCursor(entry reflect.Value, fields []*model.Field) (string, error) {
+	cursor := pageCursor{Keys: make([]json.RawMessage, len(fields))}
+	for i, field := range fields {
+		var value interface{}
+		if entry.Type() == field.Schema.ModelType {
+			value = entry.FieldByIndex(field.Index).Interface()
+		} else if structField := entry.FieldByName(field.Name); structField.IsValid() {
+			value = structField.Interface()
+		} else {
+			return "", fmt.Errorf("Keyset pagination requires the order key %s in the response, %v has no such field", field.GetFullName(), entry.Type())
+		}
+		if valuer, ok := value.(driver.Valuer); ok {
+			driverValue, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+			if bytes, ok := driverValue.([]byte); ok {
+				driverValue = string(bytes)
+			}
+			value = driverValue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Keys[i] = raw
+	}
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(encoded string, fields []*model.Field) ([]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	cursor := pageCursor{}
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	if len(cursor.Keys) != len(fields) {
+		return nil, fmt.Errorf("%w: expected %d keys, got %d", ErrInvalidCursor, len(fields), len(cursor.Keys))
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		target := reflect.New(field.IndirectFieldType)
+		if scanner, ok := target.Interface().(sql.Scanner); ok {
+			// Stored as the driver value, e.g. GeoJSON for spatial fields
+			var driverValue interface{}
+			if err := json.Unmarshal(cursor.Keys[i], &driverValue); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+			}
+			if field.DataType == model.TimestampType || field.DataType == model.TimestampTZ {
+				if text, ok := driverValue.(string); ok {
+					if parsed, err := time.Parse(time.RFC3339Nano, text); err == nil {
+						driverValue = parsed
+					}
+				}
+			}
+			if err := scanner.Scan(driverValue); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+			}
+		} else if err := json.Unmarshal(cursor.Keys[i], target.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+		}
+		values[i] = target.Elem().Interface()
+	}
+	return values, nil
+}
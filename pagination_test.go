@@ -0,0 +1,128 @@
+package atlas
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+func TestPageSeeksPastCursor(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{
+		{columns: []string{"id", "brand"}, values: [][]interface{}{{1, "b"}, {2, "a"}}},
+		{columns: []string{"id", "brand"}, values: [][]interface{}{{3, "a"}}},
+	}
+
+	cars := make([]CompileTest, 0)
+	cursor, err := db.Model("CompileTest").Select("Id", "Brand").OrderByCol("Brand", true).Page(2, &cars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor == "" || len(cars) != 2 {
+		t.Fatalf("Expected full page with cursor, got %d entries", len(cars))
+	}
+
+	cars = make([]CompileTest, 0)
+	next, err := db.Model("CompileTest").Select("Id", "Brand").OrderByCol("Brand", true).After(cursor).Page(2, &cars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "" || len(cars) != 1 {
+		t.Errorf("Expected last page without cursor, got %d entries", len(cars))
+	}
+	expected := `WHERE (("compile_test"."brand" < $1) OR ("compile_test"."brand" = $2 AND "compile_test"."id" > $3)) ` +
+		`ORDER BY "compile_test"."brand" DESC,"compile_test"."id" ASC LIMIT 2`
+	if !strings.HasSuffix(strings.TrimSuffix(fake.statements[1], ";"), expected) {
+		t.Errorf("Expected statement ending with %s, got %s", expected, fake.statements[1])
+	}
+
+	_, err = db.Model("CompileTest").OrderByCol("Brand", true).OrderByCol("Id", false).After(cursor+"x").Page(2, &cars)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected invalid cursor error, got %v", err)
+	}
+}
+
+func TestPageSeeksByDistance(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{
+		{columns: []string{"id", "location"}, values: [][]interface{}{{4, model.NewLocation(1, 1)}}},
+		{columns: []string{"id", "location"}, values: [][]interface{}{}},
+	}
+	target := model.NewLocation(0, 0)
+
+	cars := make([]CompileTest, 0)
+	cursor, err := db.Model("CompileTest").Select("Id", "Location").OrderByNearestTo(target, false).Page(1, &cars)
+	if err != nil || cursor == "" {
+		t.Fatalf("Expected cursor, got %v", err)
+	}
+	if _, err := db.Model("CompileTest").Select("Id", "Location").OrderByNearestTo(target, false).After(cursor).Page(1, &cars); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fake.statements[1], `COALESCE((SELECT "compile_test"."location"::geometry <#> ST_GeomFromGeoJSON($`) {
+		t.Errorf("Expected distance of last entry to be read by primary key, got %s", fake.statements[1])
+	}
+}
+
+func TestPageRequiresSelectedKeys(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	cars := make([]CompileTest, 0)
+	if _, err := db.Model("CompileTest").Select("Brand").OrderByCol("Brand", false).Page(2, &cars); err == nil {
+		t.Errorf("Expected error for unselected primary key")
+	}
+	if _, err := db.Model("CompileTest").Omit("Brand").OrderByCol("Brand", false).Page(2, &cars); err == nil {
+		t.Errorf("Expected error for omitted order key")
+	}
+	if err := db.RegisterModel(NullableTest{}); err != nil {
+		t.Fatal(err)
+	}
+	trips := make([]NullableTest, 0)
+	if _, err := db.Model("NullableTest").OrderByCol("EndedAt", false).Page(2, &trips); err == nil {
+		t.Errorf("Expected error for nullable order key")
+	}
+	if len(fake.statements) != 0 {
+		t.Errorf("Expected no statements, got %v", fake.statements)
+	}
+}
+
+func TestPageGroupsOrClauses(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := encodeCursor(reflect.ValueOf(CompileTest{Id: 2}), []*model.Field{db.schemas["CompileTest"].FieldsByName["Id"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{{columns: []string{"id", "brand"}}}
+
+	cars := make([]CompileTest, 0)
+	_, err = db.Model("CompileTest").Select("Id", "Brand").
+		Where(query.Or{query.Equal{Column: "Brand", Value: "a"}, query.Equal{Column: "Brand", Value: "b"}}).
+		After(cursor).Page(2, &cars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT "compile_test"."id","compile_test"."brand" FROM "compile_test" ` +
+		`WHERE ("compile_test"."brand" = $1 OR "compile_test"."brand" = $2) AND (("compile_test"."id" > $3)) ` +
+		`ORDER BY "compile_test"."id" ASC LIMIT 2;`
+	if fake.statements[0] != expected {
+		t.Errorf("Expected %s, got %s", expected, fake.statements[0])
+	}
+}
@@ -1,9 +1,12 @@
 package atlas
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/JayPeeTeeDee/atlas/adapter"
@@ -19,6 +22,10 @@ type Query struct {
 	builder     *query.Builder
 	database    *Database
 	buildErrors []error
+	ctx         context.Context
+	tx          adapter.Tx // Transaction statements are executed in, if any
+	fetchSize   int        // Rows fetched at a time through a server side cursor, see Cursor
+	after       string     // Cursor of the entry Page continues after
 
 	Echo bool
 }
@@ -35,6 +42,7 @@ func NewQuery(schema model.Schema, database *Database) *Query {
 		builder:     query.NewBuilder(),
 		database:    database,
 		buildErrors: make([]error, 0),
+		ctx:         context.Background(),
 	}
 }
 
@@ -55,6 +63,18 @@ func (q *Query) EchoQuery() *Query {
 	return q
 }
 
+// WithContext sets the context the query is executed with
+func (q *Query) WithContext(ctx context.Context) *Query {
+	q.ctx = ctx
+	return q
+}
+
+// WithTx executes the query in a transaction opened with Database.Transaction
+func (q *Query) WithTx(tx adapter.Tx) *Query {
+	q.tx = tx
+	return q
+}
+
 /* Functions for building up query */
 func (q *Query) Distinct() *Query {
 	q.builder.IsDistinct = true
@@ -278,94 +298,199 @@ func (q *Query) HasWithinRange(targets []model.SpatialObject, rangeMeters float6
 
 /* Functions for execution of query */
 
-/* SELECT STATEMENTS */
-func (q *Query) Count(count *int) error {
+// ToSQL compiles the query into a statement of the given kind without executing it.
+// Insert and update statements are built from the object passed in.
+func (q *Query) ToSQL(kind query.Type, object ...interface{}) (string, []interface{}, error) {
+	switch kind {
+	case query.InsertQuery, query.UpdateQuery:
+		if len(object) != 1 {
+			return "", nil, fmt.Errorf("Expected 1 object to compile %s, got %d", kind, len(object))
+		}
+		return q.compile(kind, object[0])
+	default:
+		return q.compile(kind, nil)
+	}
+}
+
+func (q *Query) compile(kind query.Type, object interface{}) (string, []interface{}, error) {
 	if q.Error() != nil {
-		return q.Error()
+		return "", nil, q.Error()
+	}
+	builder := *q.builder
+	builder.QueryType = kind
+	switch kind {
+	case query.InsertQuery, query.UpdateQuery:
+		vals, err := model.ParseObject(object, q.mainSchema)
+		if err != nil {
+			return "", nil, err
+		}
+		if kind == query.UpdateQuery && len(vals) > 1 {
+			return "", nil, errors.New("Can only update 1 record each time")
+		}
+		return q.compileValues(kind, vals)
 	}
-	q.builder.QueryType = query.SelectQuery
-	q.builder.IsCount = true
-	statement, args := query.CompileSQL(*q.builder, q)
-	if q.Echo {
-		fmt.Println(statement)
+	return query.CompileSQL(builder, q)
+}
+
+// compileValues compiles an insert or update statement for values already parsed from objects
+func (q *Query) compileValues(kind query.Type, vals []map[string]interface{}) (string, []interface{}, error) {
+	if q.Error() != nil {
+		return "", nil, q.Error()
 	}
-	rows, err := q.database.Query(statement, args...)
+	builder := *q.builder
+	builder.QueryType = kind
+	builder.InsertValues = vals
+	return query.CompileSQL(builder, q)
+}
+
+/* SELECT STATEMENTS */
+func (q *Query) Count(count *int) error {
+	statement, args, err := q.compile(query.CountQuery, nil)
 	if err != nil {
 		return err
 	}
-	return dbscan.ScanOne(count, rows)
+	return q.scan(query.CountQuery, statement, args, func(rows adapter.Rows) (int64, error) {
+		if err := dbscan.ScanOne(count, rows); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
 }
 
 func (q *Query) First(response interface{}) error {
-	if q.Error() != nil {
-		return q.Error()
-	}
-	q.builder.QueryType = query.SelectQuery
 	q.builder.Limit = 1
-	statement, args := query.CompileSQL(*q.builder, q)
-	if q.Echo {
-		fmt.Println(statement)
-	}
-	rows, err := q.database.Query(statement, args...)
+	statement, args, err := q.compile(query.SelectQuery, nil)
 	if err != nil {
 		return err
 	}
-	return dbscan.ScanOne(response, rows)
+	return q.scan(query.SelectQuery, statement, args, func(rows adapter.Rows) (int64, error) {
+		if err := dbscan.ScanOne(response, rows); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
 }
 
 func (q *Query) All(response interface{}) error {
-	if q.Error() != nil {
-		return q.Error()
-	}
-	q.builder.QueryType = query.SelectQuery
-	statement, args := query.CompileSQL(*q.builder, q)
-	if q.Echo {
-		fmt.Println(statement)
-	}
-	rows, err := q.database.Query(statement, args...)
-	// TODO: return wrapped error
+	statement, args, err := q.compile(query.SelectQuery, nil)
 	if err != nil {
 		return err
 	}
-
-	return dbscan.ScanAll(response, rows)
+	return q.scan(query.SelectQuery, statement, args, func(rows adapter.Rows) (int64, error) {
+		// TODO: return wrapped error
+		if err := dbscan.ScanAll(response, rows); err != nil {
+			return 0, err
+		}
+		entries := reflect.Indirect(reflect.ValueOf(response))
+		if entries.Kind() != reflect.Slice {
+			return 0, nil
+		}
+		return int64(entries.Len()), nil
+	})
 }
 
+// Create inserts the object or slice of objects.
+// Slices needing more parameters than the dialect allows in a statement are inserted in chunks within one transaction,
+// the one set with WithTx if any.
 func (q *Query) Create(object interface{}) (sql.Result, error) {
 	if q.Error() != nil {
 		return nil, q.Error()
 	}
-	q.builder.QueryType = query.InsertQuery
 	vals, err := model.ParseObject(object, q.mainSchema)
 	if err != nil {
 		return nil, err
 	}
-	q.builder.InsertValues = vals
-	statement, args := query.CompileSQL(*q.builder, q)
-	if q.Echo {
-		fmt.Println(statement)
+	fields := len(q.insertFields())
+	if fields == 0 || len(vals)*fields <= q.database.adapter.Dialect().MaxParams() {
+		statement, args, err := q.compileValues(query.InsertQuery, vals)
+		if err != nil {
+			return nil, err
+		}
+		return q.exec(query.InsertQuery, statement, args)
+	}
+
+	chunkSize := q.database.adapter.Dialect().MaxParams() / fields
+	var total int64
+	insertChunks := func(tx adapter.Tx) error {
+		txQuery := *q
+		txQuery.tx = tx
+		for offset := 0; offset < len(vals); offset += chunkSize {
+			end := offset + chunkSize
+			if end > len(vals) {
+				end = len(vals)
+			}
+			count, err := txQuery.insertValues(vals[offset:end])
+			if err != nil {
+				return err
+			}
+			total += count
+		}
+		return nil
 	}
-	return q.database.Execute(statement, args...)
+	if q.tx != nil {
+		// Chunks are part of the transaction set with WithTx, which the caller commits or rolls back
+		err = insertChunks(q.tx)
+	} else {
+		err = q.database.Transaction(q.ctx, insertChunks)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(total), nil
 }
 
 func (q *Query) Update(object interface{}) (sql.Result, error) {
-	if q.Error() != nil {
-		return nil, q.Error()
-	}
-	q.builder.QueryType = query.UpdateQuery
-	vals, err := model.ParseObject(object, q.mainSchema)
+	statement, args, err := q.compile(query.UpdateQuery, object)
 	if err != nil {
 		return nil, err
 	}
-	if len(vals) > 1 {
-		return nil, errors.New("Can only update 1 record each time")
+	return q.exec(query.UpdateQuery, statement, args)
+}
+
+func (q *Query) newEvent(kind query.Type, statement string, args []interface{}) *QueryEvent {
+	return &QueryEvent{
+		Operation:  operationOf(kind),
+		Model:      q.mainSchema.Name,
+		Table:      q.mainSchema.Table,
+		Statement:  statement,
+		Args:       args,
+		Predicates: query.SpatialPredicates(*q.builder, q),
 	}
-	q.builder.InsertValues = vals
-	statement, args := query.CompileSQL(*q.builder, q)
+}
+
+func (q *Query) exec(kind query.Type, statement string, args []interface{}) (result sql.Result, err error) {
 	if q.Echo {
 		fmt.Println(statement)
 	}
-	return q.database.Execute(statement, args...)
+	err = q.database.run(q.ctx, q.newEvent(kind, statement, args), func(ctx context.Context) (int64, error) {
+		result, err = q.executor().Exec(ctx, statement, args...)
+		return rowsAffected(result, err)
+	})
+	return
+}
+
+// scan runs a select statement and reads its rows with scanRows, which returns the number of rows read
+func (q *Query) scan(kind query.Type, statement string, args []interface{}, scanRows func(rows adapter.Rows) (int64, error)) error {
+	if q.Echo {
+		fmt.Println(statement)
+	}
+	return q.database.run(q.ctx, q.newEvent(kind, statement, args), func(ctx context.Context) (int64, error) {
+		rows, err := q.executor().Query(ctx, statement, args...)
+		if err != nil {
+			return 0, err
+		}
+		if kind == query.SelectQuery {
+			rows = decodeJSON(rows, q.jsonColumns())
+		}
+		return scanRows(rows)
+	})
+}
+
+func (q *Query) executor() executor {
+	if q.tx != nil {
+		return q.tx
+	}
+	return q.database.adapter
 }
 
 func (q *Query) parseCols(columns []string) []string {
@@ -12,11 +12,13 @@ const (
 	SelectQuery Type = "SelectQueryType"
 	InsertQuery Type = "InsertQueryType"
 	UpdateQuery Type = "UpdateQueryType"
+	DeleteQuery Type = "DeleteQueryType"
+	CountQuery  Type = "CountQueryType" // Select query returning the number of entries
 )
 
 type Builder struct {
-	Selections *utils.Set
-	Omissions  *utils.Set
+	Selections *utils.OrderedSet
+	Omissions  *utils.OrderedSet
 	Clauses    []Clause
 	Orders     []Order
 	Joins      []Join
@@ -32,8 +34,8 @@ type Builder struct {
 
 func NewBuilder() *Builder {
 	builder := &Builder{}
-	builder.Selections = utils.NewSet()
-	builder.Omissions = utils.NewSet()
+	builder.Selections = utils.NewOrderedSet()
+	builder.Omissions = utils.NewOrderedSet()
 	builder.Clauses = make([]Clause, 0)
 	builder.Orders = make([]Order, 0)
 	return builder
@@ -11,7 +11,7 @@ import (
 type Clause interface {
 	Condition() string
 	IsValid(info QueryInfo) bool
-	Sql(info QueryInfo) (string, []interface{})
+	Sql(info QueryInfo, sql *SqlBuilder) error
 }
 
 type GreaterThan struct {
@@ -20,11 +20,9 @@ type GreaterThan struct {
 	Value       interface{}
 }
 
-func (e GreaterThan) Sql(info QueryInfo) (string, []interface{}) {
-	if e.OtherColumn != "" {
-		return fmt.Sprintf("%s > %s", info.GetField(e.Column).GetFullDBName(), info.GetField(e.OtherColumn).GetFullDBName()), []interface{}{}
-	}
-	return fmt.Sprintf("%s > ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e GreaterThan) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, ">", e.OtherColumn, e.Value)
+	return nil
 }
 
 func (e GreaterThan) IsValid(info QueryInfo) bool {
@@ -54,11 +52,9 @@ type LessThan struct {
 	Value       interface{}
 }
 
-func (e LessThan) Sql(info QueryInfo) (string, []interface{}) {
-	if e.OtherColumn != "" {
-		return fmt.Sprintf("%s < %s", info.GetField(e.Column).GetFullDBName(), info.GetField(e.OtherColumn).GetFullDBName()), []interface{}{}
-	}
-	return fmt.Sprintf("%s < ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e LessThan) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, "<", e.OtherColumn, e.Value)
+	return nil
 }
 
 func (e LessThan) IsValid(info QueryInfo) bool {
@@ -88,31 +84,25 @@ type Equal struct {
 	Value       interface{}
 }
 
-func (e Equal) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
+func (e Equal) Sql(info QueryInfo, sql *SqlBuilder) error {
 	field := info.GetField(e.Column)
 	switch field.DataType {
 	case model.LocationType, model.RegionType:
-		if spatialType == adapter.PostGisExtension {
-			if e.OtherColumn != "" {
-				otherField := info.GetField(e.OtherColumn)
-				return fmt.Sprintf("ST_Equals(%s::geometry, %s::geometry)", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
-			}
-			return fmt.Sprintf("ST_Equals(%s::geometry, ST_GeomFromGeoJSON(?))", field.GetFullDBName()), []interface{}{e.Value}
+		var target adapter.SpatialArg
+		if e.OtherColumn != "" {
+			target = adapter.ColumnArg(fullDBName(info, info.GetField(e.OtherColumn)))
 		} else {
-			if e.OtherColumn != "" {
-				otherField := info.GetField(e.OtherColumn)
-				return fmt.Sprintf("%s = %s", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
-			}
-			return fmt.Sprintf("%s = ?", field.GetFullDBName()), []interface{}{e.Value}
+			target = adapter.ParamArg(sql.Param(e.Value))
 		}
-	default:
-		if e.OtherColumn != "" {
-			otherField := info.GetField(e.OtherColumn)
-			return fmt.Sprintf("%s = %s", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
+		predicate, err := sql.Dialect().SpatialPredicate(adapter.SpatialEquals, adapter.ColumnArg(fullDBName(info, field)), target)
+		if err != nil {
+			return err
 		}
-		return fmt.Sprintf("%s = ?", field.GetFullDBName()), []interface{}{e.Value}
+		sql.WriteString(predicate)
+	default:
+		writeComparison(info, sql, e.Column, "=", e.OtherColumn, e.Value)
 	}
+	return nil
 }
 
 func (e Equal) IsValid(info QueryInfo) bool {
@@ -150,31 +140,25 @@ type NotEqual struct {
 	Value       interface{}
 }
 
-func (e NotEqual) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
+func (e NotEqual) Sql(info QueryInfo, sql *SqlBuilder) error {
 	field := info.GetField(e.Column)
 	switch field.DataType {
 	case model.LocationType, model.RegionType:
-		if spatialType == adapter.PostGisExtension {
-			if e.OtherColumn != "" {
-				otherField := info.GetField(e.OtherColumn)
-				return fmt.Sprintf("NOT ST_Equals(%s::geometry, %s::geometry)", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
-			}
-			return fmt.Sprintf("NOT ST_Equals(%s::geometry, ST_GeomFromGeoJSON(?))", field.GetFullDBName()), []interface{}{e.Value}
+		var target adapter.SpatialArg
+		if e.OtherColumn != "" {
+			target = adapter.ColumnArg(fullDBName(info, info.GetField(e.OtherColumn)))
 		} else {
-			if e.OtherColumn != "" {
-				otherField := info.GetField(e.OtherColumn)
-				return fmt.Sprintf("%s <> %s", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
-			}
-			return fmt.Sprintf("%s <> ?", field.GetFullDBName()), []interface{}{e.Value}
+			target = adapter.ParamArg(sql.Param(e.Value))
 		}
-	default:
-		if e.OtherColumn != "" {
-			otherField := info.GetField(e.OtherColumn)
-			return fmt.Sprintf("%s <> %s", field.GetFullDBName(), otherField.GetFullDBName()), []interface{}{}
+		predicate, err := sql.Dialect().SpatialPredicate(adapter.SpatialEquals, adapter.ColumnArg(fullDBName(info, field)), target)
+		if err != nil {
+			return err
 		}
-		return fmt.Sprintf("%s <> ?", field.GetFullDBName()), []interface{}{e.Value}
+		sql.WriteString("NOT " + predicate)
+	default:
+		writeComparison(info, sql, e.Column, "<>", e.OtherColumn, e.Value)
 	}
+	return nil
 }
 
 func (e NotEqual) IsValid(info QueryInfo) bool {
@@ -212,11 +196,9 @@ type GreaterThanOrEqual struct {
 	Value       interface{}
 }
 
-func (e GreaterThanOrEqual) Sql(info QueryInfo) (string, []interface{}) {
-	if e.OtherColumn != "" {
-		return fmt.Sprintf("%s >= %s", info.GetField(e.Column).GetFullDBName(), info.GetField(e.OtherColumn).GetFullDBName()), []interface{}{}
-	}
-	return fmt.Sprintf("%s >= ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e GreaterThanOrEqual) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, ">=", e.OtherColumn, e.Value)
+	return nil
 }
 
 func (e GreaterThanOrEqual) IsValid(info QueryInfo) bool {
@@ -246,11 +228,9 @@ type LessThanOrEqual struct {
 	Value       interface{}
 }
 
-func (e LessThanOrEqual) Sql(info QueryInfo) (string, []interface{}) {
-	if e.OtherColumn != "" {
-		return fmt.Sprintf("%s <= %s", info.GetField(e.Column).GetFullDBName(), info.GetField(e.OtherColumn).GetFullDBName()), []interface{}{}
-	}
-	return fmt.Sprintf("%s <= ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e LessThanOrEqual) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, "<=", e.OtherColumn, e.Value)
+	return nil
 }
 
 func (e LessThanOrEqual) IsValid(info QueryInfo) bool {
@@ -279,8 +259,9 @@ type Like struct {
 	Value  string
 }
 
-func (e Like) Sql(info QueryInfo) (string, []interface{}) {
-	return fmt.Sprintf("%s LIKE ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e Like) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, "LIKE", "", e.Value)
+	return nil
 }
 
 func (e Like) IsValid(info QueryInfo) bool {
@@ -288,7 +269,7 @@ func (e Like) IsValid(info QueryInfo) bool {
 	if field == nil {
 		return false
 	} else {
-		return field.DataType == model.String
+		return field.DataType == model.String || field.DataType == model.Text
 	}
 }
 
@@ -301,8 +282,9 @@ type NotLike struct {
 	Value  string
 }
 
-func (e NotLike) Sql(info QueryInfo) (string, []interface{}) {
-	return fmt.Sprintf("%s NOT LIKE ?", info.GetField(e.Column).GetFullDBName()), []interface{}{e.Value}
+func (e NotLike) Sql(info QueryInfo, sql *SqlBuilder) error {
+	writeComparison(info, sql, e.Column, "NOT LIKE", "", e.Value)
+	return nil
 }
 
 func (e NotLike) IsValid(info QueryInfo) bool {
@@ -310,7 +292,7 @@ func (e NotLike) IsValid(info QueryInfo) bool {
 	if field == nil {
 		return false
 	} else {
-		return field.DataType == model.String
+		return field.DataType == model.String || field.DataType == model.Text
 	}
 }
 
@@ -325,17 +307,20 @@ type CoveredBy struct {
 	Target       model.SpatialObject
 }
 
-func (c CoveredBy) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
-	if spatialType == adapter.PostGisExtension {
-		if c.TargetColumn != "" {
-			return fmt.Sprintf("ST_Covers(%s, %s)", info.GetField(c.TargetColumn).GetFullDBName(), info.GetField(c.Column).GetFullDBName()), []interface{}{}
-		}
-		return fmt.Sprintf("ST_Covers(ST_GeomFromGeoJSON(?)::geography, %s)", info.GetField(c.Column).GetFullDBName()), []interface{}{c.Target}
+func (c CoveredBy) Sql(info QueryInfo, sql *SqlBuilder) error {
+	column := adapter.ColumnArg(fullDBName(info, info.GetField(c.Column)))
+	var target adapter.SpatialArg
+	if c.TargetColumn != "" {
+		target = adapter.ColumnArg(fullDBName(info, info.GetField(c.TargetColumn)))
 	} else {
-		// Not implemented
-		return "", []interface{}{}
+		target = adapter.ParamArg(sql.Param(c.Target))
+	}
+	predicate, err := sql.Dialect().SpatialPredicate(adapter.SpatialCovers, target, column)
+	if err != nil {
+		return err
 	}
+	sql.WriteString(predicate)
+	return nil
 }
 
 func (c CoveredBy) IsValid(info QueryInfo) bool {
@@ -365,17 +350,20 @@ type Covers struct {
 	Target       model.SpatialObject
 }
 
-func (c Covers) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
-	if spatialType == adapter.PostGisExtension {
-		if c.TargetColumn != "" {
-			return fmt.Sprintf("ST_Covers(%s, %s)", info.GetField(c.Column).GetFullDBName(), info.GetField(c.TargetColumn).GetFullDBName()), []interface{}{}
-		}
-		return fmt.Sprintf("ST_Covers(%s, ST_GeomFromGeoJSON(?)::geography)", info.GetField(c.Column).GetFullDBName()), []interface{}{c.Target}
+func (c Covers) Sql(info QueryInfo, sql *SqlBuilder) error {
+	column := adapter.ColumnArg(fullDBName(info, info.GetField(c.Column)))
+	var target adapter.SpatialArg
+	if c.TargetColumn != "" {
+		target = adapter.ColumnArg(fullDBName(info, info.GetField(c.TargetColumn)))
 	} else {
-		// Not implemented
-		return "", []interface{}{}
+		target = adapter.ParamArg(sql.Param(c.Target))
+	}
+	predicate, err := sql.Dialect().SpatialPredicate(adapter.SpatialCovers, column, target)
+	if err != nil {
+		return err
 	}
+	sql.WriteString(predicate)
+	return nil
 }
 
 func (c Covers) IsValid(info QueryInfo) bool {
@@ -406,29 +394,40 @@ type WithinRangeOf struct {
 	Range        float64
 }
 
-func (w WithinRangeOf) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
-	if spatialType == adapter.PostGisExtension {
-		sql := strings.Builder{}
-		vals := []interface{}{}
+// Sql writes a predicate for each target, ORed in parentheses if there are several
+func (w WithinRangeOf) Sql(info QueryInfo, sql *SqlBuilder) error {
+	dialect := sql.Dialect()
+	column := adapter.ColumnArg(fullDBName(info, info.GetField(w.Column)))
+	isFirst := true
+	grouped := len(w.Targets) > 1 || (w.TargetColumn != "" && len(w.Targets) > 0)
+	if grouped {
+		sql.WriteString("(")
+	}
 
-		if w.TargetColumn != "" {
-			sql.WriteString(fmt.Sprintf("ST_DWithin(%s, %s, ?)", info.GetField(w.Column).GetFullDBName(), info.GetField(w.TargetColumn).GetFullDBName()))
-			vals = append(vals, w.Range)
+	if w.TargetColumn != "" {
+		predicate, err := dialect.SpatialPredicate(adapter.SpatialDWithin, column, adapter.ColumnArg(fullDBName(info, info.GetField(w.TargetColumn))), adapter.ParamArg(sql.Param(w.Range)))
+		if err != nil {
+			return err
 		}
+		sql.WriteString(predicate)
+		isFirst = false
+	}
 
-		for _, targetObj := range w.Targets {
-			if sql.Len() > 0 {
-				sql.WriteString(" OR ")
-			}
-			sql.WriteString(fmt.Sprintf("ST_DWithin(%s, ST_GeomFromGeoJSON(?)::geography, ?)", info.GetField(w.Column).GetFullDBName()))
-			vals = append(vals, targetObj, w.Range)
+	for _, targetObj := range w.Targets {
+		predicate, err := dialect.SpatialPredicate(adapter.SpatialDWithin, column, adapter.ParamArg(sql.Param(targetObj)), adapter.ParamArg(sql.Param(w.Range)))
+		if err != nil {
+			return err
 		}
-		return sql.String(), vals
-	} else {
-		// Not implemented
-		return "", []interface{}{}
+		if !isFirst {
+			sql.WriteString(" OR ")
+		}
+		sql.WriteString(predicate)
+		isFirst = false
 	}
+	if grouped {
+		sql.WriteString(")")
+	}
+	return nil
 }
 
 func (w WithinRangeOf) IsValid(info QueryInfo) bool {
@@ -459,29 +458,32 @@ type HasWithinRange struct {
 	Range        float64
 }
 
-func (h HasWithinRange) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
-	if spatialType == adapter.PostGisExtension {
-		sql := strings.Builder{}
-		vals := []interface{}{}
+func (h HasWithinRange) Sql(info QueryInfo, sql *SqlBuilder) error {
+	dialect := sql.Dialect()
+	column := adapter.ColumnArg(fullDBName(info, info.GetField(h.Column)))
+	isFirst := true
 
-		if h.TargetColumn != "" {
-			sql.WriteString(fmt.Sprintf("ST_DWithin(%s, %s, ?)", info.GetField(h.Column).GetFullDBName(), info.GetField(h.TargetColumn).GetFullDBName()))
-			vals = append(vals, h.Range)
+	if h.TargetColumn != "" {
+		predicate, err := dialect.SpatialPredicate(adapter.SpatialDWithin, column, adapter.ColumnArg(fullDBName(info, info.GetField(h.TargetColumn))), adapter.ParamArg(sql.Param(h.Range)))
+		if err != nil {
+			return err
 		}
+		sql.WriteString(predicate)
+		isFirst = false
+	}
 
-		for _, targetObj := range h.Targets {
-			if sql.Len() > 0 {
-				sql.WriteString(" AND ")
-			}
-			sql.WriteString(fmt.Sprintf("ST_DWithin(%s, ST_GeomFromGeoJSON(?)::geography, ?)", info.GetField(h.Column).GetFullDBName()))
-			vals = append(vals, targetObj, h.Range)
+	for _, targetObj := range h.Targets {
+		predicate, err := dialect.SpatialPredicate(adapter.SpatialDWithin, column, adapter.ParamArg(sql.Param(targetObj)), adapter.ParamArg(sql.Param(h.Range)))
+		if err != nil {
+			return err
 		}
-		return sql.String(), vals
-	} else {
-		// Not implemented
-		return "", []interface{}{}
+		if !isFirst {
+			sql.WriteString(" AND ")
+		}
+		sql.WriteString(predicate)
+		isFirst = false
 	}
+	return nil
 }
 
 func (h HasWithinRange) IsValid(info QueryInfo) bool {
@@ -505,22 +507,117 @@ func (h HasWithinRange) Condition() string {
 	return "HasWithinRange"
 }
 
+// JSON specific clauses
+type JSONEqual struct {
+	Column string
+	Path   string // Keys separated by dots, e.g. battery.capacity
+	Value  interface{}
+}
+
+// Sql compares the value at the path as text, so Value is written in its text form, e.g. ev, 42 or true.
+// A nil Value matches missing keys and JSON null.
+func (e JSONEqual) Sql(info QueryInfo, sql *SqlBuilder) error {
+	path, err := sql.Dialect().JSONPath(fullDBName(info, info.GetField(e.Column)), strings.Split(e.Path, "."))
+	if err != nil {
+		return err
+	}
+	sql.WriteString(path)
+	if e.Value == nil {
+		sql.WriteString(" IS NULL")
+		return nil
+	}
+	sql.WriteString(" = ")
+	sql.WriteParam(jsonText(e.Value))
+	return nil
+}
+
+func (e JSONEqual) IsValid(info QueryInfo) bool {
+	field := info.GetField(e.Column)
+	if field == nil {
+		return false
+	}
+	return model.IsJSON(field.DataType) && e.Path != ""
+}
+
+func (e JSONEqual) Condition() string {
+	return "->>"
+}
+
+type JSONContains struct {
+	Column string
+	Value  interface{} // Document the column contains, e.g. map[string]interface{}{"fuel_type": "ev"}
+}
+
+func (c JSONContains) Sql(info QueryInfo, sql *SqlBuilder) error {
+	document, err := model.MarshalJSON(c.Value)
+	if err != nil {
+		return err
+	}
+	predicate, err := sql.Dialect().JSONPredicate(adapter.JSONContains, fullDBName(info, info.GetField(c.Column)), sql.Param(document))
+	if err != nil {
+		return err
+	}
+	sql.WriteString(predicate)
+	return nil
+}
+
+func (c JSONContains) IsValid(info QueryInfo) bool {
+	field := info.GetField(c.Column)
+	if field == nil {
+		return false
+	}
+	return field.DataType == model.JSONB && c.Value != nil
+}
+
+func (c JSONContains) Condition() string {
+	return "@>"
+}
+
+type JSONHasKey struct {
+	Column string
+	Key    string
+}
+
+func (h JSONHasKey) Sql(info QueryInfo, sql *SqlBuilder) error {
+	predicate, err := sql.Dialect().JSONPredicate(adapter.JSONHasKey, fullDBName(info, info.GetField(h.Column)), sql.Param(h.Key))
+	if err != nil {
+		return err
+	}
+	sql.WriteString(predicate)
+	return nil
+}
+
+func (h JSONHasKey) IsValid(info QueryInfo) bool {
+	field := info.GetField(h.Column)
+	if field == nil {
+		return false
+	}
+	return field.DataType == model.JSONB && h.Key != ""
+}
+
+func (h JSONHasKey) Condition() string {
+	return "?"
+}
+
 type Or []Clause
 
-func (e Or) Sql(info QueryInfo) (string, []interface{}) {
-	sql := strings.Builder{}
-	values := make([]interface{}, 0)
+// Sql writes the clauses in parentheses, so that they stay grouped when ANDed with other clauses
+func (e Or) Sql(info QueryInfo, sql *SqlBuilder) error {
+	if len(e) > 1 {
+		sql.WriteString("(")
+	}
 	for i, clause := range e {
-		clauseSql, clauseVals := clause.Sql(info)
-		if i == 0 {
-			sql.WriteString(clauseSql)
-		} else {
+		if i > 0 {
 			sql.WriteString(" OR ")
-			sql.WriteString(clauseSql)
 		}
-		values = append(values, clauseVals...)
+		if err := clause.Sql(info, sql); err != nil {
+			return err
+		}
+	}
+	if len(e) > 1 {
+		sql.WriteString(")")
 	}
-	return sql.String(), values
+	return nil
 }
 
 func (e Or) IsValid(info QueryInfo) bool {
@@ -538,20 +635,16 @@ func (e Or) Condition() string {
 
 type And []Clause
 
-func (e And) Sql(info QueryInfo) (string, []interface{}) {
-	sql := strings.Builder{}
-	values := make([]interface{}, 0)
+func (e And) Sql(info QueryInfo, sql *SqlBuilder) error {
 	for i, clause := range e {
-		clauseSql, clauseVals := clause.Sql(info)
-		if i == 0 {
-			sql.WriteString(clauseSql)
-		} else {
+		if i > 0 {
 			sql.WriteString(" AND ")
-			sql.WriteString(clauseSql)
 		}
-		values = append(values, clauseVals...)
+		if err := clause.Sql(info, sql); err != nil {
+			return err
+		}
 	}
-	return sql.String(), values
+	return nil
 }
 
 func (e And) IsValid(info QueryInfo) bool {
@@ -566,3 +659,54 @@ func (e And) IsValid(info QueryInfo) bool {
 func (e And) Condition() string {
 	return "AND"
 }
+
+// Raw is a clause written verbatim, with ? marking bound values (?? for a literal ?)
+type Raw struct {
+	Expression string
+	Values     []interface{}
+}
+
+func (r Raw) Sql(info QueryInfo, sql *SqlBuilder) error {
+	return sql.WriteRaw(r.Expression, r.Values...)
+}
+
+func (r Raw) IsValid(info QueryInfo) bool {
+	return true
+}
+
+func (r Raw) Condition() string {
+	return "RAW"
+}
+
+func writeComparison(info QueryInfo, sql *SqlBuilder, column string, operator string, otherColumn string, value interface{}) {
+	field := info.GetField(column)
+	sql.WriteString(fullDBName(info, field))
+	sql.WriteString(" " + operator + " ")
+	if otherColumn != "" {
+		sql.WriteString(fullDBName(info, info.GetField(otherColumn)))
+	} else {
+		writeValue(info, sql, field, value)
+	}
+}
+
+// writeValue writes the placeholder of a value compared with the field, wrapped in the write expression of its custom type
+// so that it is converted exactly as on insert
+func writeValue(info QueryInfo, sql *SqlBuilder, field *model.Field, value interface{}) {
+	if mapping, ok := customMapping(info, field); ok && mapping.Write != "" {
+		sql.WriteString(fmt.Sprintf(mapping.Write, sql.Param(value)))
+		return
+	}
+	sql.WriteParam(value)
+}
+
+// jsonText returns the text a JSON path expression reads for the value
+func jsonText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,10 +1,8 @@
 package query
 
 import (
-	"bytes"
-	"database/sql/driver"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/JayPeeTeeDee/atlas/adapter"
@@ -16,61 +14,150 @@ type Compiler struct {
 	info QueryInfo
 }
 
-func CompileSQL(builder Builder, info QueryInfo) (string, []interface{}) {
+func CompileSQL(builder Builder, info QueryInfo) (string, []interface{}, error) {
 	compiler := Compiler{info: info}
 	return compiler.compileSQL(builder)
 }
 
-func CompileTableCreation(info QueryInfo, ifNotExists bool) string {
+func CompileTableCreation(info QueryInfo, ifNotExists bool) (string, error) {
 	compiler := Compiler{info: info}
 	return compiler.compileTableCreation(ifNotExists)
 }
 
-func CompileIndexCreation(info QueryInfo, ifNotExists bool) []string {
+func CompileIndexCreation(info QueryInfo, ifNotExists bool) ([]string, error) {
 	compiler := Compiler{info: info}
 	return compiler.compileIndexCreation(ifNotExists)
 }
 
-func (c Compiler) parseSelectionField(name string) string {
+// IndexDefinition is an index created along with the table of a model
+type IndexDefinition struct {
+	Name      string
+	Columns   []string // Database names of the indexed columns
+	Unique    bool
+	Plain     bool // Indexes only the columns, without expressions, sort orders or conditions
+	Statement string
+}
+
+func CompileIndexes(info QueryInfo, ifNotExists bool) ([]IndexDefinition, error) {
+	compiler := Compiler{info: info}
+	return compiler.compileIndexes(ifNotExists)
+}
+
+// CompileTableDrop returns the statements dropping the indexes created along with the table, followed by the table
+func CompileTableDrop(info QueryInfo, ifExists bool, cascade bool) ([]string, error) {
+	compiler := Compiler{info: info}
+	return compiler.compileTableDrop(ifExists, cascade)
+}
+
+func CompileTableTruncation(info QueryInfo, cascade bool) (string, error) {
+	compiler := Compiler{info: info}
+	return compiler.compileTableTruncation(cascade)
+}
+
+// CompileTableRename returns the statements renaming the table, followed by its indexes to match the new table name
+func CompileTableRename(info QueryInfo, newTable string) ([]string, error) {
+	compiler := Compiler{info: info}
+	return compiler.compileTableRename(newTable)
+}
+
+// CompileColumnAddition returns the statement adding the column of the field to an existing table
+func CompileColumnAddition(info QueryInfo, fieldName string) (string, error) {
+	compiler := Compiler{info: info}
+	return compiler.compileColumnAddition(fieldName)
+}
+
+func (c Compiler) parseSelectionField(name string) (string, error) {
 	field := c.info.GetField(name)
+	if mapping, ok := customMapping(c.info, field); ok && mapping.Read != "" {
+		return fmt.Sprintf("%s as %s", fmt.Sprintf(mapping.Read, fullDBName(c.info, field)), quote(c.info, field.DBName)), nil
+	}
 	switch field.DataType {
 	case model.LocationType, model.RegionType:
-		if c.info.GetAdapterInfo().SpatialType() == adapter.PostGisExtension {
-			return fmt.Sprintf("ST_AsGeoJSON(%s) as %s", field.GetFullDBName(), field.DBName)
-		} else {
-			return field.GetFullDBName()
+		sql, err := c.info.GetAdapterInfo().Dialect().DecodeSpatial(fullDBName(c.info, field))
+		if err != nil {
+			return "", err
 		}
+		return fmt.Sprintf("%s as %s", sql, quote(c.info, field.DBName)), nil
 	default:
-		return field.GetFullDBName()
+		return fullDBName(c.info, field), nil
 	}
 }
 
-func (c Compiler) parseInsertionValuePlaceholder(name string) string {
-	field := c.info.GetField(name)
+func (c Compiler) parseInsertionValuePlaceholder(field *model.Field, sql *SqlBuilder, value interface{}) (string, error) {
+	if mapping, ok := customMapping(c.info, field); ok && mapping.Write != "" {
+		return fmt.Sprintf(mapping.Write, sql.Param(value)), nil
+	}
 	switch field.DataType {
 	case model.LocationType, model.RegionType:
-		if c.info.GetAdapterInfo().SpatialType() == adapter.PostGisExtension {
-			return "ST_GeomFromGeoJSON(?)::geography"
-		} else {
-			return "?"
+		return sql.Dialect().EncodeSpatial(sql.Param(value))
+	case model.JSON, model.JSONB:
+		encoded, err := model.MarshalJSON(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s as json: %w", field.GetFullName(), err)
 		}
+		return sql.Param(encoded), nil
 	default:
-		return "?"
+		return sql.Param(value), nil
 	}
 }
 
-func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
-	sql := strings.Builder{}
-	values := make([]interface{}, 0)
-	var targetFieldsSet *utils.Set
-	if builder.Selections.Size() == 0 {
-		targetSet := c.info.GetMainSchema().AllFieldNames
-		for _, schema := range c.info.GetJoinSchemas() {
-			targetSet = targetSet.Union(schema.AllFieldNames)
-		}
-		targetFieldsSet = targetSet.Difference(builder.Omissions)
-	} else {
-		targetFieldsSet = builder.Selections.Difference(builder.Omissions)
+// customMapping returns how the dialect stores the field, if it has a custom type
+func customMapping(info QueryInfo, field *model.Field) (model.ColumnMapping, bool) {
+	if field.CustomType == nil {
+		return model.ColumnMapping{}, false
+	}
+	return field.CustomType.MappingFor(string(info.GetAdapterInfo().Dialect().DatabaseType()))
+}
+
+func (c Compiler) parseSelectionFields(fields []string) (string, error) {
+	selBuilder := strings.Builder{}
+	for i, sel := range fields {
+		selection, err := c.parseSelectionField(sel)
+		if err != nil {
+			return "", err
+		}
+		selBuilder.WriteString(selection)
+		if i < len(fields)-1 {
+			selBuilder.WriteString(",")
+		}
+	}
+	return selBuilder.String(), nil
+}
+
+func (c Compiler) compileClauses(clauses []Clause, sql *SqlBuilder) error {
+	clause := clauses[0]
+	if len(clauses) > 1 {
+		clause = append(And{}, clauses...)
+	}
+	return clause.Sql(c.info, sql)
+}
+
+// SelectedFields returns the full names of the fields selected by the builder, in the order of the columns of its result
+func SelectedFields(builder Builder, info QueryInfo) []string {
+	return selectedFields(builder, info).Keys()
+}
+
+func selectedFields(builder Builder, info QueryInfo) *utils.OrderedSet {
+	if builder.Selections.Size() > 0 {
+		return builder.Selections.Difference(builder.Omissions)
+	}
+	targetSet := info.GetMainSchema().AllFieldNames
+	for _, join := range builder.Joins {
+		targetSet = targetSet.Union(info.GetJoinSchemas()[join.OtherSchema].AllFieldNames)
+	}
+	return targetSet.Difference(builder.Omissions)
+}
+
+func (c Compiler) compileSQL(builder Builder) (string, []interface{}, error) {
+	sql := NewSqlBuilder(c.info.GetAdapterInfo().Dialect())
+	if builder.QueryType == CountQuery {
+		builder.QueryType = SelectQuery
+		builder.IsCount = true
+	}
+	targetFieldsSet := selectedFields(builder, c.info)
+
+	if (builder.QueryType == InsertQuery || builder.QueryType == UpdateQuery) && len(builder.InsertValues) == 0 {
+		return "", nil, errors.New("no values to insert or update")
 	}
 
 	if builder.QueryType == UpdateQuery && len(builder.Clauses) == 0 {
@@ -86,14 +173,11 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 		if builder.IsCount {
 			if builder.IsDistinct {
 				sql.WriteString("COUNT(DISTINCT(")
-				selBuilder := strings.Builder{}
-				for i, sel := range targetFields {
-					selBuilder.WriteString(c.parseSelectionField(sel))
-					if i < len(targetFields)-1 {
-						selBuilder.WriteString(",")
-					}
+				selections, err := c.parseSelectionFields(targetFields)
+				if err != nil {
+					return "", nil, err
 				}
-				sql.WriteString(selBuilder.String())
+				sql.WriteString(selections)
 				sql.WriteString(")) ")
 			} else {
 				sql.WriteString("COUNT(*) ")
@@ -102,15 +186,12 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 			if builder.IsDistinct {
 				sql.WriteString("DISTINCT ")
 			}
-			selBuilder := strings.Builder{}
-			for i, sel := range targetFields {
-				selBuilder.WriteString(c.parseSelectionField(sel))
-				if i < len(targetFields)-1 {
-					selBuilder.WriteString(",")
-				}
+			selections, err := c.parseSelectionFields(targetFields)
+			if err != nil {
+				return "", nil, err
 			}
-			selBuilder.WriteString(" ")
-			sql.WriteString(selBuilder.String())
+			sql.WriteString(selections)
+			sql.WriteString(" ")
 		}
 
 		sql.WriteString("FROM ")
@@ -120,34 +201,36 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 
 	case UpdateQuery:
 		sql.WriteString("UPDATE ")
+
+	case DeleteQuery:
+		sql.WriteString("DELETE FROM ")
+
+	default:
+		return "", nil, fmt.Errorf("unknown query type: %s", builder.QueryType)
 	}
 
-	sql.WriteString(c.info.GetMainSchema().Table + " ")
+	sql.WriteString(quote(c.info, c.info.GetMainSchema().Table) + " ")
 
 	switch qType := builder.QueryType; qType {
 	case SelectQuery:
 		for _, join := range builder.Joins {
-			joinSql, joinVals := join.Sql(c.info)
-			sql.WriteString(joinSql)
-			values = append(values, joinVals...)
+			if err := join.Sql(c.info, sql); err != nil {
+				return "", nil, err
+			}
 			sql.WriteString(" ")
 		}
 		if len(builder.Clauses) > 0 {
 			sql.WriteString("WHERE ")
-			clause := builder.Clauses[0]
-			if len(builder.Clauses) > 1 {
-				clause = append(And{}, builder.Clauses...)
+			if err := c.compileClauses(builder.Clauses, sql); err != nil {
+				return "", nil, err
 			}
-			clauseSql, clauseValues := clause.Sql(c.info)
-			sql.WriteString(clauseSql)
-			values = append(values, clauseValues...)
 		}
 		if len(builder.Orders) > 0 {
 			sql.WriteString(" ORDER BY ")
 			for i, order := range builder.Orders {
-				clauseSql, clauseValues := order.Sql(c.info)
-				sql.WriteString(clauseSql)
-				values = append(values, clauseValues...)
+				if err := order.Sql(c.info, sql); err != nil {
+					return "", nil, err
+				}
 				if i < len(builder.Orders)-1 {
 					sql.WriteString(",")
 				}
@@ -163,9 +246,12 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 		}
 
 	case InsertQuery:
+		// Fields are resolved once, not for every row inserted
+		fields := make([]*model.Field, len(targetFields))
 		sql.WriteString("(")
 		for i, key := range targetFields {
-			sql.WriteString(c.info.GetField(key).DBName)
+			fields[i] = c.info.GetField(key)
+			sql.WriteString(quote(c.info, fields[i].DBName))
 			if i < len(targetFields)-1 {
 				sql.WriteString(",")
 			}
@@ -175,9 +261,12 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 
 		for i, insertVal := range builder.InsertValues {
 			sql.WriteString("(")
-			for k, key := range targetFields {
-				sql.WriteString(c.parseInsertionValuePlaceholder(key))
-				values = append(values, insertVal[c.info.GetField(key).Name])
+			for k, field := range fields {
+				placeholder, err := c.parseInsertionValuePlaceholder(field, sql, insertVal[field.Name])
+				if err != nil {
+					return "", nil, err
+				}
+				sql.WriteString(placeholder)
 				if k < len(targetFields)-1 {
 					sql.WriteString(",")
 				}
@@ -191,52 +280,67 @@ func (c Compiler) compileSQL(builder Builder) (string, []interface{}) {
 		insertVal := builder.InsertValues[0]
 		sql.WriteString("SET ")
 		for i, key := range targetFields {
-			sql.WriteString(c.info.GetField(key).DBName)
+			field := c.info.GetField(key)
+			sql.WriteString(quote(c.info, field.DBName))
 			sql.WriteString(" = ")
-			sql.WriteString(c.parseInsertionValuePlaceholder(key))
-			values = append(values, insertVal[c.info.GetField(key).Name])
+			placeholder, err := c.parseInsertionValuePlaceholder(field, sql, insertVal[field.Name])
+			if err != nil {
+				return "", nil, err
+			}
+			sql.WriteString(placeholder)
 			if i < len(targetFields)-1 {
 				sql.WriteString(",")
 			}
 		}
 		sql.WriteString(" WHERE ")
 		if len(builder.Clauses) > 0 {
-			clause := builder.Clauses[0]
-			if len(builder.Clauses) > 1 {
-				clause = append(And{}, builder.Clauses...)
+			if err := c.compileClauses(builder.Clauses, sql); err != nil {
+				return "", nil, err
 			}
-			clauseSql, clauseValues := clause.Sql(c.info)
-			sql.WriteString(clauseSql)
-			values = append(values, clauseValues...)
 		} else {
 			primaryClauses := And{}
 			for _, field := range c.info.GetMainSchema().PrimaryFields {
 				primaryClauses = append(primaryClauses, Equal{Column: field.Name, Value: insertVal[field.Name]})
 			}
-			clauseSql, clauseValues := primaryClauses.Sql(c.info)
-			sql.WriteString(clauseSql)
-			values = append(values, clauseValues...)
+			if err := primaryClauses.Sql(c.info, sql); err != nil {
+				return "", nil, err
+			}
+		}
+	case DeleteQuery:
+		if len(builder.Clauses) == 0 {
+			return "", nil, errors.New("delete query requires at least 1 where clause")
+		}
+		sql.WriteString("WHERE ")
+		if err := c.compileClauses(builder.Clauses, sql); err != nil {
+			return "", nil, err
 		}
 	}
 	sql.WriteString(";")
-	return replacePlaceholder(sql.String(), c.info.GetAdapterInfo().Placeholder()), values
+	return sql.String(), sql.Args(), nil
 }
 
-func (c Compiler) compileTableCreation(ifNotExists bool) string {
+func (c Compiler) compileTableCreation(ifNotExists bool) (string, error) {
 	schema := c.info.GetMainSchema()
 	sql := strings.Builder{}
 	sql.WriteString("CREATE TABLE ")
 	if ifNotExists {
 		sql.WriteString("IF NOT EXISTS ")
 	}
-	sql.WriteString(schema.Table)
+	sql.WriteString(quote(c.info, schema.Table))
 	sql.WriteString(" (")
 
 	for i, field := range schema.Fields {
-		sql.WriteString(field.DBName)
+		fieldType, err := c.info.GetAdapterInfo().Dialect().ColumnType(field)
+		if err != nil {
+			return "", err
+		}
+		qualifiers, err := c.parseFieldQualifiers(field)
+		if err != nil {
+			return "", err
+		}
+		sql.WriteString(quote(c.info, field.DBName))
 		sql.WriteString(" ")
-		sql.WriteString(c.parseFieldType(field))
-		qualifiers := c.parseFieldQualifiers(field)
+		sql.WriteString(fieldType)
 		sql.WriteString(qualifiers)
 		if i < len(schema.Fields)-1 {
 			sql.WriteString(", ")
@@ -245,150 +349,187 @@ func (c Compiler) compileTableCreation(ifNotExists bool) string {
 
 	sql.WriteString(");")
 
-	return replacePlaceholder(sql.String(), c.info.GetAdapterInfo().Placeholder())
+	return sql.String(), nil
 }
 
-func (c Compiler) compileIndexCreation(ifNotExists bool) []string {
+func (c Compiler) compileIndexCreation(ifNotExists bool) ([]string, error) {
+	indexes, err := c.compileIndexes(ifNotExists)
+	if err != nil {
+		return nil, err
+	}
+	allStatements := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		allStatements = append(allStatements, index.Statement)
+	}
+	return allStatements, nil
+}
+
+func (c Compiler) compileIndexes(ifNotExists bool) ([]IndexDefinition, error) {
 	schema := c.info.GetMainSchema()
-	allStatements := make([]string, 0)
+	dialect := c.info.GetAdapterInfo().Dialect()
+	indexes := make([]IndexDefinition, 0)
 
 	// Create indexes for spatial types
-	for _, fieldName := range schema.LocationFieldNames.Keys() {
+	spatialFieldNames := append(schema.LocationFieldNames.Keys(), schema.RegionFieldNames.Keys()...)
+	for _, fieldName := range spatialFieldNames {
 		field := c.info.GetField(fieldName)
 		indexName := fmt.Sprintf("idx_%s_%s", schema.Table, field.DBName)
-		if ifNotExists {
-			indexName = "IF NOT EXISTS " + indexName
+		statement, err := dialect.SpatialIndex(quote(c.info, indexName), quote(c.info, schema.Table), quote(c.info, field.DBName), ifNotExists)
+		if err != nil {
+			return nil, err
 		}
-		allStatements = append(allStatements, fmt.Sprintf("CREATE INDEX %s ON %s USING GIST (%s);", indexName, schema.Table, field.DBName))
+		indexes = append(indexes, IndexDefinition{Name: indexName, Columns: []string{field.DBName}, Plain: true, Statement: statement})
 	}
 
-	for _, fieldName := range schema.RegionFieldNames.Keys() {
-		field := c.info.GetField(fieldName)
-		indexName := fmt.Sprintf("idx_%s_%s", schema.Table, field.DBName)
-		if ifNotExists {
-			indexName = "IF NOT EXISTS " + indexName
+	// Create indexes declared with field tags
+	for _, index := range schema.Indexes {
+		definition := IndexDefinition{Name: index.Name, Unique: index.Unique, Plain: index.Where == "" && len(index.Include) == 0}
+		if index.Type != "" && !strings.EqualFold(index.Type, "btree") {
+			definition.Plain = false
+		}
+		spec := adapter.Index{
+			Name:   quote(c.info, index.Name),
+			Table:  quote(c.info, schema.Table),
+			Unique: index.Unique,
+			Method: index.Type,
+			Where:  index.Where,
+		}
+		for _, column := range index.Include {
+			spec.Include = append(spec.Include, quote(c.info, column))
+		}
+		for _, indexField := range index.Fields {
+			key := quote(c.info, indexField.Field.DBName)
+			if indexField.Expression != "" {
+				key = "(" + indexField.Expression + ")"
+				definition.Plain = false
+			}
+			if indexField.Sort != "" {
+				key += " " + indexField.Sort
+				definition.Plain = false
+			}
+			spec.Keys = append(spec.Keys, key)
+			definition.Columns = append(definition.Columns, indexField.Field.DBName)
 		}
-		allStatements = append(allStatements, fmt.Sprintf("CREATE INDEX %s ON %s USING GIST (%s);", indexName, schema.Table, field.DBName))
+		statement, err := dialect.CreateIndex(spec, ifNotExists)
+		if err != nil {
+			return nil, err
+		}
+		definition.Statement = statement
+		indexes = append(indexes, definition)
 	}
 
-	return allStatements
+	return indexes, nil
 }
 
-func (c Compiler) parseFieldQualifiers(field *model.Field) string {
-	qualifiers := strings.Builder{}
-	adapterInfo := c.info.GetAdapterInfo()
-	switch adapterInfo.DatabaseType() {
-	case adapter.PostgreSQL:
-		if field.PrimaryKey {
-			qualifiers.WriteString(" PRIMARY KEY")
-		} else {
-			if field.NotNull {
-				qualifiers.WriteString(" NOT NULL")
-			}
-			if field.Unique {
-				qualifiers.WriteString(" UNIQUE")
-			}
-		}
-		if !field.AutoIncrement && field.HasDefaultValue {
-			if field.DefaultValue == nil {
-				qualifiers.WriteString(" DEFAULT NULL")
-			} else {
-				switch v := field.DefaultValue.(type) {
-				case model.Location, model.Region:
-					val, err := v.(driver.Valuer).Value()
-					if err == nil {
-						switch adapterInfo.SpatialType() {
-						case adapter.PostGisExtension:
-							geom := bytes.NewBuffer(val.([]uint8))
-							qualifiers.WriteString(fmt.Sprintf(" DEFAULT ST_GeomFromGeoJSON('%v')::geography", geom.String()))
-						default:
-							qualifiers.WriteString(fmt.Sprintf(" DEFAULT %v", val))
-						}
-					}
-				case driver.Valuer:
-					val, err := v.Value()
-					if err == nil {
-						qualifiers.WriteString(fmt.Sprintf(" DEFAULT %v", val))
-					}
-				default:
-					qualifiers.WriteString(fmt.Sprintf(" DEFAULT %v", toString(v)))
-				}
-			}
+func (c Compiler) compileTableDrop(ifExists bool, cascade bool) ([]string, error) {
+	schema := c.info.GetMainSchema()
+	dialect := c.info.GetAdapterInfo().Dialect()
+	indexes, err := c.compileIndexes(false)
+	if err != nil {
+		return nil, err
+	}
+	allStatements := make([]string, 0, len(indexes)+1)
+	for _, index := range indexes {
+		// Indexes may be missing from tables not created through atlas
+		statement, err := dialect.DropIndex(quote(c.info, index.Name), quote(c.info, schema.Table), true)
+		if err != nil {
+			return nil, err
 		}
+		allStatements = append(allStatements, statement)
 	}
-	return qualifiers.String()
+	statement, err := dialect.DropTable(quote(c.info, schema.Table), ifExists, cascade)
+	if err != nil {
+		return nil, err
+	}
+	return append(allStatements, statement), nil
+}
+
+func (c Compiler) compileTableTruncation(cascade bool) (string, error) {
+	return c.info.GetAdapterInfo().Dialect().TruncateTable(quote(c.info, c.info.GetMainSchema().Table), cascade)
 }
 
-func (c Compiler) parseFieldType(field *model.Field) string {
-	switch c.info.GetAdapterInfo().DatabaseType() {
-	case adapter.PostgreSQL:
-		if field.AutoIncrement {
-			return "serial"
-		}
-		switch field.DataType {
-		case model.Bool:
-			return "bool"
-		case model.Int:
-			return "int"
-		case model.Uint:
-			return "int" // unsigned not supported by postgresql
-		case model.String:
-			return "varchar(255)" // TODO: might not be long enough, allow spec
-		case model.Float:
-			return "float64"
-		case model.Time:
-			return "time"
-		case model.Bytes:
-			return "bytea"
-		case model.LocationType:
-			return "geography(point)"
-		case model.RegionType:
-			return "geography(polygon)"
-		case model.TimestampType:
-			return "timestamp"
-		default:
-			return string(field.DataType)
+func (c Compiler) compileTableRename(newTable string) ([]string, error) {
+	schema := c.info.GetMainSchema()
+	dialect := c.info.GetAdapterInfo().Dialect()
+	if newTable == "" {
+		return nil, errors.New("No table name to rename " + schema.Table + " to")
+	}
+	statement, err := dialect.RenameTable(quote(c.info, schema.Table), quote(c.info, newTable))
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := c.compileIndexes(false)
+	if err != nil {
+		return nil, err
+	}
+	allStatements := []string{statement}
+	for _, index := range indexes {
+		prefix := fmt.Sprintf("idx_%s_", schema.Table)
+		if !strings.HasPrefix(index.Name, prefix) {
+			continue
 		}
-	default:
-		return ""
+		newName := fmt.Sprintf("idx_%s_%s", newTable, strings.TrimPrefix(index.Name, prefix))
+		statement, err := dialect.RenameIndex(quote(c.info, index.Name), quote(c.info, newTable), quote(c.info, newName))
+		if err != nil {
+			return nil, err
+		}
+		allStatements = append(allStatements, statement)
+	}
+	return allStatements, nil
+}
+
+func (c Compiler) compileColumnAddition(fieldName string) (string, error) {
+	schema := c.info.GetMainSchema()
+	field := c.info.GetField(fieldName)
+	if field == nil {
+		return "", errors.New("No such field: " + fieldName)
 	}
+	fieldType, err := c.info.GetAdapterInfo().Dialect().ColumnType(field)
+	if err != nil {
+		return "", err
+	}
+	qualifiers, err := c.parseFieldQualifiers(field)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s%s;", quote(c.info, schema.Table), quote(c.info, field.DBName), fieldType, qualifiers), nil
 }
-func replacePlaceholder(sqlString string, style adapter.PlaceholderStyle) string {
-	switch style {
-	case adapter.DollarPlaceholder:
-		for nParam := 1; strings.Contains(sqlString, "?"); nParam++ {
-			sqlString = strings.Replace(sqlString, "?", fmt.Sprintf("$%d", nParam), 1)
+
+func (c Compiler) parseFieldQualifiers(field *model.Field) (string, error) {
+	qualifiers := strings.Builder{}
+	if field.PrimaryKey {
+		qualifiers.WriteString(" PRIMARY KEY")
+	} else {
+		if field.NotNull {
+			qualifiers.WriteString(" NOT NULL")
+		}
+		if field.Unique {
+			qualifiers.WriteString(" UNIQUE")
 		}
-	default:
-		return sqlString
 	}
-	return sqlString
+	if !field.AutoIncrement && field.HasDefaultValue {
+		value := field.DefaultValue
+		if model.IsJSON(field.DataType) {
+			encoded, err := model.MarshalJSON(value)
+			if err != nil {
+				return "", err
+			}
+			value = encoded
+		}
+		defaultValue, err := c.info.GetAdapterInfo().Dialect().ColumnDefault(value)
+		if err != nil {
+			return "", err
+		}
+		qualifiers.WriteString(" DEFAULT ")
+		qualifiers.WriteString(defaultValue)
+	}
+	return qualifiers.String(), nil
+}
+
+func quote(info QueryInfo, identifier string) string {
+	return info.GetAdapterInfo().Dialect().QuoteIdentifier(identifier)
 }
 
-func toString(value interface{}) string {
-	switch v := value.(type) {
-	case string:
-		return v
-	case int:
-		return strconv.FormatInt(int64(v), 10)
-	case int8:
-		return strconv.FormatInt(int64(v), 10)
-	case int16:
-		return strconv.FormatInt(int64(v), 10)
-	case int32:
-		return strconv.FormatInt(int64(v), 10)
-	case int64:
-		return strconv.FormatInt(v, 10)
-	case uint:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint8:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint16:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint32:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint64:
-		return strconv.FormatUint(v, 10)
-	}
-	return ""
+func fullDBName(info QueryInfo, field *model.Field) string {
+	return quote(info, field.Schema.Table) + "." + quote(info, field.DBName)
 }
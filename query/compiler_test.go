@@ -0,0 +1,431 @@
+package query
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+type testInfo struct {
+	mainSchema  model.Schema
+	joinSchemas map[string]model.Schema
+}
+
+func newTestInfo(target interface{}) testInfo {
+	schema, err := model.Parse(target)
+	if err != nil {
+		panic(err)
+	}
+	return testInfo{mainSchema: *schema, joinSchemas: make(map[string]model.Schema)}
+}
+
+func (t testInfo) splitFieldName(field string) (string, string) {
+	vals := strings.Split(field, ".")
+	if len(vals) == 1 {
+		return t.mainSchema.Name, vals[0]
+	}
+	return vals[0], vals[1]
+}
+
+func (t testInfo) HasSchema(schema string) bool {
+	_, inJoin := t.joinSchemas[schema]
+	return schema == t.mainSchema.Name || inJoin
+}
+
+func (t testInfo) HasField(field string) bool {
+	return t.GetField(field) != nil
+}
+
+func (t testInfo) HasFieldOfType(field string, datatype model.DataType) bool {
+	return t.HasField(field) && t.GetField(field).DataType == datatype
+}
+
+func (t testInfo) GetField(field string) *model.Field {
+	schema, fieldName := t.splitFieldName(field)
+	if schema == t.mainSchema.Name {
+		return t.mainSchema.FieldsByName[fieldName]
+	}
+	if joinSchema, ok := t.joinSchemas[schema]; ok {
+		return joinSchema.FieldsByName[fieldName]
+	}
+	return nil
+}
+
+func (t testInfo) GetMainSchema() model.Schema {
+	return t.mainSchema
+}
+
+func (t testInfo) GetJoinSchemas() map[string]model.Schema {
+	return t.joinSchemas
+}
+
+func (t testInfo) GetAdapterInfo() adapter.AdapterInfo {
+	return adapter.PostgresAdapter{}
+}
+
+type User struct {
+	Id       int `atlas:"primarykey"`
+	Group    string
+	UserName string `atlas:"column:UserName"`
+	Location model.Location
+}
+
+func TestCompileQuotesIdentifiers(t *testing.T) {
+	info := newTestInfo(User{})
+	builder := NewBuilder()
+	builder.QueryType = SelectQuery
+	builder.Selections.AddAll("User.Group", "User.Location")
+	builder.Where(Equal{Column: "UserName", Value: "abc"})
+
+	statement, args, err := CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"user"."group"`,
+		`ST_AsGeoJSON("user"."location") as "location"`,
+		`FROM "user" `,
+		`WHERE "user"."UserName" = $1;`,
+	} {
+		if !strings.Contains(statement, expected) {
+			t.Errorf("Expected %s in %s", expected, statement)
+		}
+	}
+	if len(args) != 1 || args[0] != "abc" {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestCompileTableCreationQuotesIdentifiers(t *testing.T) {
+	info := newTestInfo(User{})
+	statement, err := CompileTableCreation(info, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `CREATE TABLE IF NOT EXISTS "user" ("id" int PRIMARY KEY, "group" varchar(255), "UserName" varchar(255), "location" geography(point));`
+	if statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+
+	statements, err := CompileIndexCreation(info, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `CREATE INDEX "idx_user_location" ON "user" USING GIST ("location");`
+	if len(statements) != 1 || statements[0] != expected {
+		t.Errorf("Expected %s, got %v", expected, statements)
+	}
+}
+
+type Vehicle struct {
+	Id        int    `atlas:"primarykey"`
+	Brand     string `atlas:"index"`
+	TenantId  int    `atlas:"index:idx_tenant_created"`
+	CreatedAt int    `atlas:"index:idx_tenant_created,sort:desc,include:Brand"`
+	Plate     string `atlas:"uniqueindex,expression:lower(plate),where:plate <> ''"`
+	Tags      string `atlas:"index:idx_vehicle_tags,type:gin"`
+}
+
+func TestCompileDeclaredIndexes(t *testing.T) {
+	info := newTestInfo(Vehicle{})
+	statements, err := CompileIndexCreation(info, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`CREATE INDEX IF NOT EXISTS "idx_vehicle_brand" ON "vehicle" ("brand");`,
+		`CREATE INDEX IF NOT EXISTS "idx_tenant_created" ON "vehicle" ("tenant_id", "created_at" DESC) INCLUDE ("brand");`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "idx_vehicle_plate" ON "vehicle" ((lower(plate))) WHERE plate <> '';`,
+		`CREATE INDEX IF NOT EXISTS "idx_vehicle_tags" ON "vehicle" USING GIN ("tags");`,
+	}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(statements, "\n"))
+	}
+}
+
+// Route is a line stored as GeoJSON, mapped with model.RegisterType
+type Route struct {
+	GeoJSON string
+}
+
+func (r Route) Value() (driver.Value, error) {
+	return r.GeoJSON, nil
+}
+
+func (r *Route) Scan(value interface{}) error {
+	r.GeoJSON, _ = value.(string)
+	return nil
+}
+
+type Delivery struct {
+	Id    int `atlas:"primarykey"`
+	Route Route
+}
+
+func TestCompileCustomType(t *testing.T) {
+	err := model.RegisterType(Route{}, model.CustomType{
+		DataType: "route",
+		Dialects: map[string]model.ColumnMapping{
+			string(adapter.PostgreSQL): {ColumnType: "geography(linestring)", Read: "ST_AsGeoJSON(%s)", Write: "ST_GeomFromGeoJSON(%s)::geography"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := newTestInfo(Delivery{})
+
+	statement, err := CompileTableCreation(info, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `CREATE TABLE "delivery" ("id" int PRIMARY KEY, "route" geography(linestring));`; statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+
+	builder := NewBuilder()
+	builder.QueryType = SelectQuery
+	builder.Selections.AddAll("Delivery.Id", "Delivery.Route")
+	statement, _, err = CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `ST_AsGeoJSON("delivery"."route") as "route"`; !strings.Contains(statement, expected) {
+		t.Errorf("Expected %s in %s", expected, statement)
+	}
+
+	builder = NewBuilder()
+	builder.QueryType = InsertQuery
+	builder.InsertValues = []map[string]interface{}{{"Id": 1, "Route": Route{GeoJSON: "{}"}}}
+	statement, _, err = CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `VALUES ($1,ST_GeomFromGeoJSON($2)::geography)`; !strings.Contains(statement, expected) {
+		t.Errorf("Expected %s in %s", expected, statement)
+	}
+
+	builder = NewBuilder()
+	builder.QueryType = SelectQuery
+	builder.Selections.Add("Delivery.Id")
+	builder.Where(Equal{Column: "Route", Value: Route{GeoJSON: "{}"}})
+	statement, _, err = CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `SELECT "delivery"."id" FROM "delivery" WHERE "delivery"."route" = ST_GeomFromGeoJSON($1)::geography;`; statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+}
+
+type Telemetry struct {
+	Id    int `atlas:"primarykey"`
+	Attrs map[string]interface{}
+	Raw   []string `atlas:"type:json"`
+}
+
+func TestCompileJSON(t *testing.T) {
+	info := newTestInfo(Telemetry{})
+
+	statement, err := CompileTableCreation(info, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `CREATE TABLE "telemetry" ("id" int PRIMARY KEY, "attrs" jsonb, "raw" json);`; statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+
+	builder := NewBuilder()
+	builder.QueryType = InsertQuery
+	builder.InsertValues = []map[string]interface{}{{"Id": 1, "Attrs": map[string]interface{}{"fuel_type": "ev"}, "Raw": []string(nil)}}
+	_, args, err := CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 3 || args[1] != `{"fuel_type":"ev"}` || args[2] != nil {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	builder = NewBuilder()
+	builder.QueryType = SelectQuery
+	builder.Selections.AddAll("Telemetry.Id")
+	builder.Where(And{
+		JSONEqual{Column: "Attrs", Path: "fuel_type", Value: "ev"},
+		JSONEqual{Column: "Attrs", Path: "battery.capacity", Value: 75},
+		JSONContains{Column: "Attrs", Value: map[string]interface{}{"doors": 4}},
+		JSONHasKey{Column: "Attrs", Key: "range"},
+	})
+	statement, args, err = CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `WHERE "telemetry"."attrs"->>'fuel_type' = $1 AND "telemetry"."attrs"->'battery'->>'capacity' = $2 AND ` +
+		`"telemetry"."attrs" @> $3::jsonb AND "telemetry"."attrs" ? $4;`
+	if !strings.Contains(statement, expected) {
+		t.Errorf("Expected %s in %s", expected, statement)
+	}
+	if len(args) != 4 || args[0] != "ev" || args[1] != "75" || args[2] != `{"doors":4}` || args[3] != "range" {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	for _, clause := range []Clause{
+		JSONEqual{Column: "Id", Path: "fuel_type", Value: "ev"},
+		JSONEqual{Column: "Attrs", Value: "ev"},
+		JSONContains{Column: "Raw", Value: []string{"a"}},
+		JSONHasKey{Column: "Attrs"},
+	} {
+		if clause.IsValid(info) {
+			t.Errorf("Expected %+v to be invalid", clause)
+		}
+	}
+}
+
+func TestCompileTableDropAndRename(t *testing.T) {
+	info := newTestInfo(User{})
+	statements, err := CompileTableDrop(info, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`DROP INDEX IF EXISTS "idx_user_location";`, `DROP TABLE IF EXISTS "user" CASCADE;`}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, statements)
+	}
+
+	statement, err := CompileTableTruncation(info, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement != `TRUNCATE TABLE "user";` {
+		t.Errorf("Unexpected truncation: %s", statement)
+	}
+
+	statements, err = CompileTableRename(info, "old_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{`ALTER TABLE "user" RENAME TO "old_user";`, `ALTER INDEX IF EXISTS "idx_user_location" RENAME TO "idx_old_user_location";`}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, statements)
+	}
+}
+
+type Car struct {
+	Id            int `atlas:"primarykey"`
+	Brand         string
+	Model         string
+	Location      model.Location
+	OperationZone model.Region
+}
+
+type Garage struct {
+	Id   int `atlas:"primarykey"`
+	Area model.Region
+}
+
+func TestCompileGolden(t *testing.T) {
+	location := model.NewLocation(103.8, 1.3)
+	tests := []struct {
+		name      string
+		build     func(builder *Builder)
+		statement string
+		args      int
+	}{
+		{
+			name: "select",
+			build: func(builder *Builder) {
+				builder.QueryType = SelectQuery
+				builder.Where(Equal{Column: "Brand", Value: "Toyota"})
+				builder.OrderBy(ColumnOrder{Column: "Id", Descending: true})
+				builder.Limit = 10
+			},
+			statement: `SELECT "car"."id","car"."brand","car"."model",ST_AsGeoJSON("car"."location") as "location",ST_AsGeoJSON("car"."operation_zone") as "operation_zone" FROM "car" WHERE "car"."brand" = $1 ORDER BY "car"."id" DESC LIMIT 10;`,
+			args:      1,
+		},
+		{
+			name: "count distinct",
+			build: func(builder *Builder) {
+				builder.QueryType = SelectQuery
+				builder.IsCount = true
+				builder.IsDistinct = true
+				builder.Selections.AddAll("Car.Model", "Car.Brand")
+			},
+			statement: `SELECT COUNT(DISTINCT("car"."model","car"."brand")) FROM "car" ;`,
+		},
+		{
+			name: "spatial",
+			build: func(builder *Builder) {
+				builder.QueryType = SelectQuery
+				builder.Omissions.AddAll("Car.OperationZone", "Car.Model")
+				builder.Where(WithinRangeOf{Column: "Location", Targets: []model.SpatialObject{location, location}, Range: 10})
+				builder.OrderBy(SpatialOrder{Column: "Location", Target: location})
+			},
+			statement: `SELECT "car"."id","car"."brand",ST_AsGeoJSON("car"."location") as "location" FROM "car" WHERE (ST_DWithin("car"."location", ST_GeomFromGeoJSON($1)::geography, $2) OR ST_DWithin("car"."location", ST_GeomFromGeoJSON($3)::geography, $4)) ORDER BY "car"."location"::geometry <#> ST_GeomFromGeoJSON($5) ASC;`,
+			args:      5,
+		},
+		{
+			name: "insert",
+			build: func(builder *Builder) {
+				builder.QueryType = InsertQuery
+				builder.Omissions.Add("Car.OperationZone")
+				builder.InsertValues = []map[string]interface{}{
+					{"Id": 1, "Brand": "Toyota", "Model": "Corolla", "Location": location},
+					{"Id": 2, "Brand": "Honda", "Model": "Civic", "Location": location},
+				}
+			},
+			statement: `INSERT INTO "car" ("id","brand","model","location") VALUES ($1,$2,$3,ST_GeomFromGeoJSON($4)::geography),($5,$6,$7,ST_GeomFromGeoJSON($8)::geography);`,
+			args:      8,
+		},
+		{
+			name: "update",
+			build: func(builder *Builder) {
+				builder.QueryType = UpdateQuery
+				builder.Omissions.Add("Car.OperationZone")
+				builder.InsertValues = []map[string]interface{}{
+					{"Id": 1, "Brand": "Toyota", "Model": "Corolla", "Location": location},
+				}
+			},
+			statement: `UPDATE "car" SET "brand" = $1,"model" = $2,"location" = ST_GeomFromGeoJSON($3)::geography WHERE "car"."id" = $4;`,
+			args:      4,
+		},
+	}
+
+	for _, test := range tests {
+		info := newTestInfo(Car{})
+		builder := NewBuilder()
+		test.build(builder)
+		statement, args, err := CompileSQL(*builder, info)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if statement != test.statement {
+			t.Errorf("%s:\nexpected %s\ngot      %s", test.name, test.statement, statement)
+		}
+		if len(args) != test.args {
+			t.Errorf("%s: expected %d args, got %d", test.name, test.args, len(args))
+		}
+	}
+}
+
+func TestCompileJoinGolden(t *testing.T) {
+	info := newTestInfo(Car{})
+	garage, err := model.Parse(Garage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.joinSchemas["Garage"] = *garage
+	builder := NewBuilder()
+	builder.QueryType = SelectQuery
+	builder.Omissions.AddAll("Car.OperationZone", "Car.Model", "Car.Brand")
+	builder.Join(Join{Schema: "Car", OtherSchema: "Garage", Type: InnerJoin, JoinClause: CoveredBy{Column: "Location", TargetColumn: "Garage.Area"}})
+
+	statement, _, err := CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT "car"."id",ST_AsGeoJSON("car"."location") as "location","garage"."id",ST_AsGeoJSON("garage"."area") as "area" FROM "car" JOIN "garage" ON ST_Covers("garage"."area", "car"."location") ;`
+	if statement != expected {
+		t.Errorf("\nexpected %s\ngot      %s", expected, statement)
+	}
+}
@@ -0,0 +1,48 @@
+package query
+
+import "github.com/JayPeeTeeDee/atlas/model"
+
+// SpatialPredicates lists the conditions of the spatial clauses, joins and orderings used by the builder
+func SpatialPredicates(builder Builder, info QueryInfo) []string {
+	predicates := make([]string, 0)
+	for _, clause := range builder.Clauses {
+		predicates = appendSpatialConditions(predicates, clause, info)
+	}
+	for _, join := range builder.Joins {
+		predicates = appendSpatialConditions(predicates, join.JoinClause, info)
+	}
+	for _, order := range builder.Orders {
+		if _, ok := order.(SpatialOrder); ok {
+			predicates = append(predicates, "DistanceOrder")
+		}
+	}
+	return predicates
+}
+
+func appendSpatialConditions(predicates []string, clause Clause, info QueryInfo) []string {
+	switch c := clause.(type) {
+	case And:
+		for _, inner := range c {
+			predicates = appendSpatialConditions(predicates, inner, info)
+		}
+	case Or:
+		for _, inner := range c {
+			predicates = appendSpatialConditions(predicates, inner, info)
+		}
+	case CoveredBy, Covers, WithinRangeOf, HasWithinRange:
+		predicates = append(predicates, c.Condition())
+	case Equal:
+		if isSpatialField(info, c.Column) {
+			predicates = append(predicates, "SpatialEqual")
+		}
+	case NotEqual:
+		if isSpatialField(info, c.Column) {
+			predicates = append(predicates, "SpatialNotEqual")
+		}
+	}
+	return predicates
+}
+
+func isSpatialField(info QueryInfo, column string) bool {
+	return info.HasFieldOfType(column, model.LocationType) || info.HasFieldOfType(column, model.RegionType)
+}
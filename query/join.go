@@ -18,9 +18,9 @@ type Join struct {
 	JoinClause  Clause
 }
 
-func (j Join) Sql(info QueryInfo) (string, []interface{}) {
-	clauseSql, vals := j.JoinClause.Sql(info)
-	return fmt.Sprintf("%s %s ON %s", j.Type, info.GetJoinSchemas()[j.OtherSchema].Table, clauseSql), vals
+func (j Join) Sql(info QueryInfo, sql *SqlBuilder) error {
+	sql.WriteString(fmt.Sprintf("%s %s ON ", j.Type, quote(info, info.GetJoinSchemas()[j.OtherSchema].Table)))
+	return j.JoinClause.Sql(info, sql)
 }
 
 func (j Join) IsValid(info QueryInfo) bool {
@@ -1,8 +1,6 @@
 package query
 
 import (
-	"fmt"
-
 	"github.com/JayPeeTeeDee/atlas/adapter"
 	"github.com/JayPeeTeeDee/atlas/model"
 )
@@ -10,7 +8,7 @@ import (
 type Order interface {
 	IsDescending() bool
 	IsValid(info QueryInfo) bool
-	Sql(info QueryInfo) (string, []interface{})
+	Sql(info QueryInfo, sql *SqlBuilder) error
 }
 
 type ColumnOrder struct {
@@ -18,14 +16,22 @@ type ColumnOrder struct {
 	Descending bool
 }
 
-func (c ColumnOrder) Sql(info QueryInfo) (string, []interface{}) {
-	sql := fmt.Sprintf("%s ", info.GetField(c.Column).DBName)
+func (c ColumnOrder) Sql(info QueryInfo, sql *SqlBuilder) error {
+	if err := c.expression(info, sql); err != nil {
+		return err
+	}
 	if c.Descending {
-		sql += "DESC"
+		sql.WriteString(" DESC")
 	} else {
-		sql += "ASC"
+		sql.WriteString(" ASC")
 	}
-	return sql, []interface{}{}
+	return nil
+}
+
+// expression writes the value rows are ordered by, without the direction
+func (c ColumnOrder) expression(info QueryInfo, sql *SqlBuilder) error {
+	sql.WriteString(fullDBName(info, info.GetField(c.Column)))
+	return nil
 }
 
 func (c ColumnOrder) IsValid(info QueryInfo) bool {
@@ -48,24 +54,34 @@ type SpatialOrder struct {
 	TargetColumn string
 }
 
-func (s SpatialOrder) Sql(info QueryInfo) (string, []interface{}) {
-	spatialType := info.GetAdapterInfo().SpatialType()
-	sql := ""
-	switch spatialType {
-	case adapter.PostGisExtension:
-		if s.TargetColumn != "" {
-			return fmt.Sprintf("%s::geometry <#> %s::geometry", info.GetField(s.TargetColumn).GetFullDBName(), info.GetField(s.Column).GetFullDBName()), []interface{}{}
+func (s SpatialOrder) Sql(info QueryInfo, sql *SqlBuilder) error {
+	dialect := sql.Dialect()
+	if s.TargetColumn != "" {
+		distance, err := dialect.SpatialDistance(adapter.ColumnArg(fullDBName(info, info.GetField(s.TargetColumn))), adapter.ColumnArg(fullDBName(info, info.GetField(s.Column))))
+		if err != nil {
+			return err
 		}
-		sql += fmt.Sprintf("%s::geometry <#> ST_GeomFromGeoJSON(?) ", info.GetField(s.Column).DBName)
-	default:
-		sql += fmt.Sprintf("%s::geometry <#> ST_GeomFromGeoJSON(?) ", info.GetField(s.Column).DBName)
+		sql.WriteString(distance)
+		return nil
+	}
+	if err := s.expression(info, sql); err != nil {
+		return err
 	}
 	if s.Descending {
-		sql += "DESC"
+		sql.WriteString(" DESC")
 	} else {
-		sql += "ASC"
+		sql.WriteString(" ASC")
+	}
+	return nil
+}
+
+func (s SpatialOrder) expression(info QueryInfo, sql *SqlBuilder) error {
+	distance, err := sql.Dialect().SpatialDistance(adapter.ColumnArg(fullDBName(info, info.GetField(s.Column))), adapter.ParamArg(sql.Param(s.Target)))
+	if err != nil {
+		return err
 	}
-	return sql, []interface{}{s.Target}
+	sql.WriteString(distance)
+	return nil
 }
 
 func (s SpatialOrder) IsValid(info QueryInfo) bool {
@@ -0,0 +1,124 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+)
+
+// SeekKey is a key of a keyset seek: an order of the query and the value it had for the last row of the previous page.
+// For a SpatialOrder, Value is the spatial object of the last row, so that its distance is computed by the database exactly as in the order.
+type SeekKey struct {
+	Order Order
+	Value interface{}
+}
+
+// Seek selects the rows following a row in the order of its keys.
+// The keys must be the orders of the query, ending with a unique key (e.g. the primary key) so that the order is total.
+type Seek struct {
+	Keys []SeekKey
+}
+
+func (s Seek) Sql(info QueryInfo, sql *SqlBuilder) error {
+	if len(s.Keys) == 0 {
+		return errors.New("seek requires at least 1 key")
+	}
+	// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys
+	sql.WriteString("(")
+	for i, key := range s.Keys {
+		if i > 0 {
+			sql.WriteString(" OR ")
+		}
+		sql.WriteString("(")
+		for _, previous := range s.Keys[:i] {
+			if err := s.writeComparison(info, sql, previous, "="); err != nil {
+				return err
+			}
+			sql.WriteString(" AND ")
+		}
+		operator := ">"
+		if key.Order.IsDescending() {
+			operator = "<"
+		}
+		if err := s.writeComparison(info, sql, key, operator); err != nil {
+			return err
+		}
+		sql.WriteString(")")
+	}
+	sql.WriteString(")")
+	return nil
+}
+
+func (s Seek) writeComparison(info QueryInfo, sql *SqlBuilder, key SeekKey, operator string) error {
+	switch order := key.Order.(type) {
+	case ColumnOrder:
+		if err := order.expression(info, sql); err != nil {
+			return err
+		}
+		sql.WriteString(" " + operator + " ")
+		writeValue(info, sql, info.GetField(order.Column), key.Value)
+	case SpatialOrder:
+		if order.TargetColumn != "" {
+			return errors.New("seek is not supported for distances between columns")
+		}
+		if err := order.expression(info, sql); err != nil {
+			return err
+		}
+		sql.WriteString(" " + operator + " ")
+		return s.writeDistance(info, sql, order, key.Value)
+	default:
+		return fmt.Errorf("seek is not supported for order %T", key.Order)
+	}
+	return nil
+}
+
+// writeDistance writes the distance of the last row, read from the table by primary key so that it is exactly
+// the value the row was ordered by. The distance recomputed from the spatial object of the row is only used if
+// the row no longer exists, as spatial values read back through GeoJSON are rounded.
+func (s Seek) writeDistance(info QueryInfo, sql *SqlBuilder, order SpatialOrder, value interface{}) error {
+	primaryKeys := make([]SeekKey, 0)
+	for _, key := range s.Keys {
+		if column, ok := key.Order.(ColumnOrder); ok && info.GetField(column.Column).PrimaryKey {
+			primaryKeys = append(primaryKeys, key)
+		}
+	}
+	recomputed, err := sql.Dialect().SpatialDistance(adapter.ParamArg(sql.Param(value)), adapter.ParamArg(sql.Param(order.Target)))
+	if err != nil {
+		return err
+	}
+	if len(primaryKeys) == 0 {
+		sql.WriteString(recomputed)
+		return nil
+	}
+
+	sql.WriteString("COALESCE((SELECT ")
+	if err := order.expression(info, sql); err != nil {
+		return err
+	}
+	sql.WriteString(" FROM " + quote(info, info.GetMainSchema().Table) + " WHERE ")
+	for i, key := range primaryKeys {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		if err := key.Order.(ColumnOrder).expression(info, sql); err != nil {
+			return err
+		}
+		sql.WriteString(" = " + sql.Param(key.Value))
+	}
+	sql.WriteString("), " + recomputed + ")")
+	return nil
+}
+
+func (s Seek) IsValid(info QueryInfo) bool {
+	for _, key := range s.Keys {
+		if !key.Order.IsValid(info) {
+			return false
+		}
+	}
+	return len(s.Keys) > 0
+}
+
+func (s Seek) Condition() string {
+	return "SEEK"
+}
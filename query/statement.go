@@ -0,0 +1,99 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+)
+
+// SqlBuilder accumulates generated SQL together with its bound arguments.
+// Parameters are numbered by the dialect as they are appended, so literal text written
+// to the builder is never rewritten.
+type SqlBuilder struct {
+	sql     strings.Builder
+	args    []interface{}
+	dialect adapter.Dialect
+}
+
+func NewSqlBuilder(dialect adapter.Dialect) *SqlBuilder {
+	return &SqlBuilder{args: make([]interface{}, 0), dialect: dialect}
+}
+
+func (b *SqlBuilder) WriteString(sql string) {
+	b.sql.WriteString(sql)
+}
+
+// Param binds value as the next parameter and returns its placeholder without writing it
+func (b *SqlBuilder) Param(value interface{}) string {
+	b.args = append(b.args, value)
+	return b.dialect.BindVar(len(b.args))
+}
+
+func (b *SqlBuilder) WriteParam(value interface{}) {
+	b.sql.WriteString(b.Param(value))
+}
+
+// WriteRaw writes a raw SQL fragment using ? as parameter markers.
+// Markers inside string literals, quoted identifiers and comments are left untouched,
+// and ?? is written as a literal ? (e.g. for the JSONB key exists operator).
+func (b *SqlBuilder) WriteRaw(sql string, args ...interface{}) error {
+	nArg := 0
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+		switch {
+		case char == '\'' || char == '"':
+			end := strings.IndexByte(sql[i+1:], char)
+			if end < 0 {
+				return fmt.Errorf("unterminated quote in raw sql: %s", sql)
+			}
+			b.sql.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case char == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			b.sql.WriteString(sql[i : i+end])
+			i += end - 1
+		case char == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i:], "*/")
+			if end < 0 {
+				return fmt.Errorf("unterminated comment in raw sql: %s", sql)
+			}
+			b.sql.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case char == '?' && strings.HasPrefix(sql[i:], "??"):
+			b.sql.WriteByte('?')
+			i++
+		case char == '?':
+			if nArg >= len(args) {
+				return fmt.Errorf("not enough arguments for raw sql: %s", sql)
+			}
+			b.WriteParam(args[nArg])
+			nArg++
+		default:
+			b.sql.WriteByte(char)
+		}
+	}
+	if nArg != len(args) {
+		return fmt.Errorf("too many arguments for raw sql: %s", sql)
+	}
+	return nil
+}
+
+func (b *SqlBuilder) Len() int {
+	return b.sql.Len()
+}
+
+func (b *SqlBuilder) String() string {
+	return b.sql.String()
+}
+
+func (b *SqlBuilder) Args() []interface{} {
+	return b.args
+}
+
+func (b *SqlBuilder) Dialect() adapter.Dialect {
+	return b.dialect
+}
@@ -0,0 +1,77 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+)
+
+func TestWriteRawKeepsLiterals(t *testing.T) {
+	sql := NewSqlBuilder(adapter.PostgresDialect{})
+	sql.WriteParam(1)
+	err := sql.WriteRaw(` AND note = 'why?' AND "col?" = ? AND attrs ?? 'key' -- really?`+"\n"+`AND b = ?`, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `$1 AND note = 'why?' AND "col?" = $2 AND attrs ? 'key' -- really?` + "\n" + `AND b = $3`
+	if sql.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, sql.String())
+	}
+	if len(sql.Args()) != 3 {
+		t.Errorf("Unexpected args: %v", sql.Args())
+	}
+}
+
+func TestWriteRawArgumentMismatch(t *testing.T) {
+	sql := NewSqlBuilder(adapter.PostgresDialect{})
+	if err := sql.WriteRaw("a = ? AND b = ?", 1); err == nil {
+		t.Errorf("Expected error for missing argument")
+	}
+	sql = NewSqlBuilder(adapter.PostgresDialect{})
+	if err := sql.WriteRaw("a = ?", 1, 2); err == nil {
+		t.Errorf("Expected error for extra argument")
+	}
+}
+
+type Note struct {
+	Id   int `atlas:"primarykey"`
+	Text string
+}
+
+func TestCompileLargeInsert(t *testing.T) {
+	info := newTestInfo(Note{})
+	builder := NewBuilder()
+	builder.QueryType = InsertQuery
+	for i := 0; i < 10000; i++ {
+		builder.InsertValues = append(builder.InsertValues, map[string]interface{}{"Id": i, "Text": "what?"})
+	}
+	statement, args, err := CompileSQL(*builder, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 20000 {
+		t.Errorf("Expected 20000 args, got %d", len(args))
+	}
+	if !strings.HasSuffix(statement, "($19999,$20000);") {
+		t.Errorf("Unexpected statement ending: %s", statement[len(statement)-30:])
+	}
+}
+
+func TestCompileDefaultWithQuestionMark(t *testing.T) {
+	schema, err := model.Parse(Note{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.FieldsByName["Text"].HasDefaultValue = true
+	schema.FieldsByName["Text"].DefaultValue = "it's ok?"
+	statement, err := CompileTableCreation(testInfo{mainSchema: *schema}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `CREATE TABLE "note" ("id" int PRIMARY KEY, "text" varchar(255) DEFAULT 'it''s ok?');`
+	if statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+}
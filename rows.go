@@ -0,0 +1,212 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/JayPeeTeeDee/atlas/adapter"
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+	"github.com/georgysavva/scany/dbscan"
+)
+
+// ErrStopIteration can be returned by the callback of Iterate to stop without an error
+var ErrStopIteration = errors.New("stop iteration")
+
+var cursorCount uint64
+
+// Rows is a cursor over the result of a select query, which reads and decodes one row at a time.
+// Rows must be closed, unless Next has returned false.
+type Rows struct {
+	ctx     context.Context
+	rows    adapter.Rows
+	scanner *dbscan.RowScanner
+	err     error
+	closed  bool
+
+	// Server side cursor, only set if the query was built with Cursor
+	database    *Database // Runs the cursor statements through the hooks of the database
+	schema      model.Schema
+	tx          adapter.Tx
+	ownsTx      bool
+	cursor      string
+	fetchSize   int
+	fetched     int
+	jsonColumns []bool // Positions of the json columns, which fetched rows unmarshal
+}
+
+func (r *Rows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+	if r.rows.Next() {
+		r.fetched++
+		return true
+	}
+	if r.err = r.rows.Err(); r.err == nil && r.cursor != "" && r.fetched == r.fetchSize {
+		// The batch was full, so the cursor may have more rows
+		r.rows.Close()
+		if r.err = r.fetch(); r.err == nil {
+			return r.Next()
+		}
+	}
+	r.Close()
+	return false
+}
+
+// Scan decodes the current row into dest, a pointer to a struct of the model
+func (r *Rows) Scan(dest interface{}) error {
+	if r.closed {
+		return errors.New("rows are closed")
+	}
+	return r.scanner.Scan(dest)
+}
+
+// Err returns the error that stopped Next, if any
+func (r *Rows) Err() error {
+	return r.err
+}
+
+func (r *Rows) Close() error {
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+	err := r.rows.Close()
+	if r.cursor != "" {
+		statement := "CLOSE " + r.cursor
+		closeErr := r.database.run(r.ctx, r.newEvent(OpExec, statement), func(ctx context.Context) (int64, error) {
+			_, err := r.tx.Exec(ctx, statement)
+			return 0, err
+		})
+		if err == nil {
+			err = closeErr
+		}
+		if r.ownsTx {
+			// The transaction only holds the cursor, nothing needs to be committed
+			if rollbackErr := r.tx.Rollback(); err == nil {
+				err = rollbackErr
+			}
+		}
+	}
+	if r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+func (r *Rows) fetch() error {
+	statement := fmt.Sprintf("FETCH FORWARD %d FROM %s", r.fetchSize, r.cursor)
+	var rows adapter.Rows
+	err := r.database.run(r.ctx, r.newEvent(OpQuery, statement), func(ctx context.Context) (int64, error) {
+		var err error
+		rows, err = r.tx.Query(ctx, statement)
+		return 0, err
+	})
+	if err != nil {
+		return err
+	}
+	r.rows = decodeJSON(rows, r.jsonColumns)
+	r.scanner = dbscan.NewRowScanner(r.rows)
+	r.fetched = 0
+	return nil
+}
+
+// newEvent returns the event of a statement on the cursor
+func (r *Rows) newEvent(operation Operation, statement string) *QueryEvent {
+	return &QueryEvent{Operation: operation, Model: r.schema.Name, Table: r.schema.Table, Statement: statement}
+}
+
+// Cursor makes Rows and Iterate read the result through a server side cursor, fetchSize rows at a time.
+// This keeps memory bounded on both the client and the driver for very large scans, at the cost of a transaction
+// held open until the rows are closed.
+func (q *Query) Cursor(fetchSize int) *Query {
+	if fetchSize <= 0 {
+		q.buildErrors = append(q.buildErrors, fmt.Errorf("Invalid cursor fetch size: %d", fetchSize))
+	}
+	q.fetchSize = fetchSize
+	return q
+}
+
+// Rows executes the select query and returns a cursor over its result
+func (q *Query) Rows() (*Rows, error) {
+	statement, args, err := q.compile(query.SelectQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	if q.fetchSize == 0 {
+		var rows adapter.Rows
+		err = q.scan(query.SelectQuery, statement, args, func(result adapter.Rows) (int64, error) {
+			rows = result
+			return 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Rows{ctx: q.ctx, rows: rows, scanner: dbscan.NewRowScanner(rows)}, nil
+	}
+
+	cursor := &Rows{
+		ctx:         q.ctx,
+		database:    q.database,
+		schema:      q.mainSchema,
+		tx:          q.tx,
+		cursor:      fmt.Sprintf("atlas_cursor_%d", atomic.AddUint64(&cursorCount, 1)),
+		fetchSize:   q.fetchSize,
+		jsonColumns: q.jsonColumns(),
+	}
+	if cursor.tx == nil {
+		// Cursors only live as long as the transaction they are declared in
+		if cursor.tx, err = q.database.adapter.Begin(q.ctx); err != nil {
+			return nil, err
+		}
+		cursor.ownsTx = true
+	}
+	txQuery := *q
+	txQuery.tx = cursor.tx
+	if _, err = txQuery.exec(query.SelectQuery, "DECLARE "+cursor.cursor+" NO SCROLL CURSOR FOR "+statement, args); err == nil {
+		err = cursor.fetch()
+	}
+	if err != nil {
+		if cursor.ownsTx {
+			cursor.tx.Rollback()
+		}
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Iterate calls fn with every row of the result, decoded one at a time.
+// fn must be a func(row *Model) error, e.g. func(car *Car) error.
+// Iteration stops at the first error returned by fn, which is returned by Iterate unless it is ErrStopIteration.
+func (q *Query) Iterate(fn interface{}) error {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.In(0).Kind() != reflect.Ptr ||
+		fnType.NumOut() != 1 || fnType.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return fmt.Errorf("Iterate expects a func(row *T) error, got %T", fn)
+	}
+	rowType := fnType.In(0).Elem()
+
+	rows, err := q.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := reflect.New(rowType)
+		if err := rows.Scan(row.Interface()); err != nil {
+			return err
+		}
+		if result := fnValue.Call([]reflect.Value{row})[0]; !result.IsNil() {
+			if err := result.Interface().(error); !errors.Is(err, ErrStopIteration) {
+				return err
+			}
+			return nil
+		}
+	}
+	return rows.Err()
+}
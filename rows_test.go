@@ -0,0 +1,92 @@
+package atlas
+
+import (
+	"strings"
+	"testing"
+)
+
+func carRows(ids ...int) *fakeRows {
+	rows := &fakeRows{columns: []string{"id", "brand"}}
+	for _, id := range ids {
+		rows.values = append(rows.values, []interface{}{id, "brand"})
+	}
+	return rows
+}
+
+func TestIterateStopsEarly(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	result := carRows(1, 2, 3)
+	fake.results = []*fakeRows{result}
+
+	seen := make([]int, 0)
+	err := db.Model("CompileTest").Select("Id", "Brand").Iterate(func(car *CompileTest) error {
+		seen = append(seen, car.Id)
+		if car.Id == 2 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[1] != 2 || !result.closed {
+		t.Errorf("Expected iteration to stop after 2 rows and close them, got %v", seen)
+	}
+
+	if err := db.Model("CompileTest").Iterate(func(car CompileTest) error { return nil }); err == nil {
+		t.Errorf("Expected error for callback not taking a pointer")
+	}
+}
+
+func TestRowsWithServerSideCursor(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+	fake.results = []*fakeRows{carRows(1, 2), carRows(3)}
+	hook := &recordingHook{}
+	db.AddHook(hook)
+
+	rows, err := db.Model("CompileTest").Select("Id", "Brand").Cursor(2).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, 0)
+	for rows.Next() {
+		car := CompileTest{}
+		if err := rows.Scan(&car); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, car.Id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("Expected 3 rows over 2 fetches, got %v", ids)
+	}
+
+	if len(fake.statements) != 4 || !strings.HasPrefix(fake.statements[0], "DECLARE atlas_cursor_") ||
+		!strings.HasPrefix(fake.statements[1], "FETCH FORWARD 2 FROM atlas_cursor_") || !strings.HasPrefix(fake.statements[3], "CLOSE atlas_cursor_") {
+		t.Errorf("Unexpected statements %v", fake.statements)
+	}
+	if fake.rollbacks != 1 {
+		t.Errorf("Expected cursor transaction to be ended")
+	}
+
+	// Every statement on the cursor is seen by the hooks
+	operations := []Operation{OpSelect, OpQuery, OpQuery, OpExec}
+	if len(hook.after) != len(operations) {
+		t.Fatalf("Expected %d events, got %d", len(operations), len(hook.after))
+	}
+	for i, event := range hook.after {
+		if event.Operation != operations[i] || event.Statement != fake.statements[i] || event.Table != "compile_test" {
+			t.Errorf("Unexpected event %d: %+v", i, event)
+		}
+	}
+}
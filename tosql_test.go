@@ -0,0 +1,104 @@
+package atlas
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/JayPeeTeeDee/atlas/model"
+	"github.com/JayPeeTeeDee/atlas/query"
+)
+
+type CompileTest struct {
+	Id       int `atlas:"primarykey"`
+	Brand    string
+	Location model.Location
+}
+
+func TestToSQLWithoutConnection(t *testing.T) {
+	db, err := NewDatabase(DBType_Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.RegisterModel(CompileTest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object := CompileTest{Id: 1, Brand: "Toyota", Location: model.NewLocation(103.8, 1.3)}
+	tests := []struct {
+		kind      query.Type
+		object    []interface{}
+		statement string
+		args      int
+	}{
+		{query.SelectQuery, nil, `SELECT "compile_test"."id","compile_test"."brand",ST_AsGeoJSON("compile_test"."location") as "location" FROM "compile_test" WHERE "compile_test"."brand" = $1;`, 1},
+		{query.CountQuery, nil, `SELECT COUNT(*) FROM "compile_test" WHERE "compile_test"."brand" = $1;`, 1},
+		{query.InsertQuery, []interface{}{object}, `INSERT INTO "compile_test" ("id","brand","location") VALUES ($1,$2,ST_GeomFromGeoJSON($3)::geography);`, 3},
+		{query.UpdateQuery, []interface{}{object}, `UPDATE "compile_test" SET "id" = $1,"brand" = $2,"location" = ST_GeomFromGeoJSON($3)::geography WHERE "compile_test"."brand" = $4;`, 4},
+		{query.DeleteQuery, nil, `DELETE FROM "compile_test" WHERE "compile_test"."brand" = $1;`, 1},
+	}
+	for _, test := range tests {
+		statement, args, err := db.Model("CompileTest").Where(query.Equal{Column: "Brand", Value: "Toyota"}).ToSQL(test.kind, test.object...)
+		if err != nil {
+			t.Fatalf("%s: %s", test.kind, err)
+		}
+		if statement != test.statement {
+			t.Errorf("%s:\nexpected %s\ngot      %s", test.kind, test.statement, statement)
+		}
+		if len(args) != test.args {
+			t.Errorf("%s: expected %d args, got %d", test.kind, test.args, len(args))
+		}
+	}
+
+	_, err = db.Create(object)
+	if err == nil {
+		t.Errorf("Expected error when executing without connection")
+	}
+}
+
+type NullableTest struct {
+	Id        int `atlas:"primarykey"`
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Note      sql.NullString
+}
+
+func TestCreateTableInfersNotNull(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	db.InferNotNull(true)
+	if err := db.RegisterModel(NullableTest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateTable("NullableTest", false); err != nil {
+		t.Fatal(err)
+	}
+	expected := `CREATE TABLE "nullable_test" ("id" int PRIMARY KEY, "started_at" timestamptz NOT NULL, "ended_at" timestamptz, "note" varchar(255));`
+	if len(fake.statements) != 1 || fake.statements[0] != expected {
+		t.Errorf("Expected %s, got %v", expected, fake.statements)
+	}
+}
+
+func TestDropTableEvent(t *testing.T) {
+	fake := &fakeAdapter{}
+	db := newFakeDatabase(fake)
+	hook := &recordingHook{}
+	db.AddHook(hook)
+	if err := db.RegisterModel(CompileTest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.DropTable("CompileTest", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(hook.after) != 1 || hook.after[0].Operation != OpDropTable || hook.after[0].Table != "compile_test" {
+		t.Fatalf("Unexpected events: %v", hook.before)
+	}
+	if len(fake.statements) != 2 || fake.commits != 1 {
+		t.Errorf("Expected index and table drop in one transaction, got %v", fake.statements)
+	}
+	if err := db.DropTable("Missing", true, false); err == nil {
+		t.Error("Expected error for unregistered schema")
+	}
+}
@@ -0,0 +1,86 @@
+// Package tracing records OpenTelemetry spans for statements executed by atlas.
+package tracing
+
+import (
+	"context"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/JayPeeTeeDee/atlas/tracing"
+
+const (
+	OperationKey  = attribute.Key("db.operation")
+	TableKey      = attribute.Key("db.sql.table")
+	StatementKey  = attribute.Key("db.statement")
+	ModelKey      = attribute.Key("atlas.model")
+	PredicatesKey = attribute.Key("atlas.spatial_predicates")
+	RowsKey       = attribute.Key("atlas.rows_affected")
+)
+
+// Hook opens a span for every statement executed by the database it is added to.
+// Spans are children of the span in the context the query is executed with (see Query.WithContext).
+type Hook struct {
+	tracer        trace.Tracer
+	withStatement bool
+}
+
+type Option func(h *Hook)
+
+// WithTracerProvider sets the provider spans are created from, instead of the global provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(h *Hook) {
+		h.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithoutStatement leaves the SQL statement out of the span attributes
+func WithoutStatement() Option {
+	return func(h *Hook) {
+		h.withStatement = false
+	}
+}
+
+func NewHook(options ...Option) *Hook {
+	hook := &Hook{tracer: otel.Tracer(instrumentationName), withStatement: true}
+	for _, option := range options {
+		option(hook)
+	}
+	return hook
+}
+
+func (h *Hook) BeforeQuery(ctx context.Context, event *atlas.QueryEvent) context.Context {
+	name := "atlas." + string(event.Operation)
+	if event.Table != "" {
+		name += " " + event.Table
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		OperationKey.String(string(event.Operation)),
+	}
+	if event.Table != "" {
+		attributes = append(attributes, TableKey.String(event.Table), ModelKey.String(event.Model))
+	}
+	if len(event.Predicates) > 0 {
+		attributes = append(attributes, PredicatesKey.StringSlice(event.Predicates))
+	}
+	if h.withStatement {
+		attributes = append(attributes, StatementKey.String(event.Statement))
+	}
+	ctx, _ = h.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return ctx
+}
+
+func (h *Hook) AfterQuery(ctx context.Context, event *atlas.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(RowsKey.Int64(event.RowsAffected))
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
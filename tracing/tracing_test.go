@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JayPeeTeeDee/atlas"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHookRecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	hook := NewHook(WithTracerProvider(provider))
+
+	parentCtx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	event := &atlas.QueryEvent{
+		Operation:  atlas.OpSelect,
+		Model:      "Car",
+		Table:      "car",
+		Statement:  `SELECT "car"."id" FROM "car" WHERE ST_Covers(...)`,
+		Predicates: []string{"CoveredBy"},
+	}
+	ctx := hook.BeforeQuery(parentCtx, event)
+	event.RowsAffected = 3
+	hook.AfterQuery(ctx, event)
+
+	failed := &atlas.QueryEvent{Operation: atlas.OpCreateTable, Model: "Car", Table: "car", Err: errors.New("boom")}
+	hook.AfterQuery(hook.BeforeQuery(parentCtx, failed), failed)
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "atlas.select car" {
+		t.Errorf("Unexpected span name: %s", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Span does not follow caller context")
+	}
+	attributes := attribute.NewSet(span.Attributes()...)
+	if value, _ := attributes.Value(TableKey); value.AsString() != "car" {
+		t.Errorf("Unexpected table attribute: %v", value)
+	}
+	if value, _ := attributes.Value(PredicatesKey); len(value.AsStringSlice()) != 1 || value.AsStringSlice()[0] != "CoveredBy" {
+		t.Errorf("Unexpected predicates attribute: %v", value)
+	}
+	if value, _ := attributes.Value(RowsKey); value.AsInt64() != 3 {
+		t.Errorf("Unexpected rows attribute: %v", value)
+	}
+
+	if spans[1].Name() != "atlas.create_table car" || spans[1].Status().Code != codes.Error {
+		t.Errorf("Expected failed create table span, got %s %v", spans[1].Name(), spans[1].Status())
+	}
+}
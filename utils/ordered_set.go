@@ -0,0 +1,77 @@
+package utils
+
+// OrderedSet is a set of strings that remembers insertion order,
+// so that iterating over its keys is deterministic.
+type OrderedSet struct {
+	keys []string
+	vals map[string]struct{}
+}
+
+func NewOrderedSet() *OrderedSet {
+	s := &OrderedSet{}
+	s.keys = make([]string, 0)
+	s.vals = make(map[string]struct{})
+	return s
+}
+
+func (s *OrderedSet) Add(value string) {
+	if _, exists := s.vals[value]; exists {
+		return
+	}
+	s.vals[value] = struct{}{}
+	s.keys = append(s.keys, value)
+}
+
+func (s *OrderedSet) AddAll(values ...string) {
+	for _, val := range values {
+		s.Add(val)
+	}
+}
+
+func (s *OrderedSet) Remove(value string) {
+	if _, exists := s.vals[value]; !exists {
+		return
+	}
+	delete(s.vals, value)
+	for i, key := range s.keys {
+		if key == value {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *OrderedSet) Contains(value string) bool {
+	_, exists := s.vals[value]
+	return exists
+}
+
+// Keys returns the values in the order they were first added
+func (s *OrderedSet) Keys() []string {
+	keys := make([]string, len(s.keys))
+	copy(keys, s.keys)
+	return keys
+}
+
+// Union returns the values of s followed by the values of other not in s
+func (s *OrderedSet) Union(other *OrderedSet) *OrderedSet {
+	newSet := NewOrderedSet()
+	newSet.AddAll(s.keys...)
+	newSet.AddAll(other.keys...)
+	return newSet
+}
+
+// Difference returns the values of s not in other, keeping the order of s
+func (s *OrderedSet) Difference(other *OrderedSet) *OrderedSet {
+	newSet := NewOrderedSet()
+	for _, k := range s.keys {
+		if !other.Contains(k) {
+			newSet.Add(k)
+		}
+	}
+	return newSet
+}
+
+func (s *OrderedSet) Size() int {
+	return len(s.keys)
+}
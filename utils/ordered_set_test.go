@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderedSetKeepsInsertionOrder(t *testing.T) {
+	set := NewOrderedSet()
+	set.AddAll("c", "a", "b", "a")
+	if !reflect.DeepEqual(set.Keys(), []string{"c", "a", "b"}) {
+		t.Errorf("Unexpected keys: %v", set.Keys())
+	}
+
+	other := NewOrderedSet()
+	other.AddAll("d", "a")
+	if keys := set.Union(other).Keys(); !reflect.DeepEqual(keys, []string{"c", "a", "b", "d"}) {
+		t.Errorf("Unexpected union: %v", keys)
+	}
+	if keys := set.Difference(other).Keys(); !reflect.DeepEqual(keys, []string{"c", "b"}) {
+		t.Errorf("Unexpected difference: %v", keys)
+	}
+
+	set.Remove("a")
+	if set.Contains("a") || set.Size() != 2 || !reflect.DeepEqual(set.Keys(), []string{"c", "b"}) {
+		t.Errorf("Unexpected keys after removal: %v", set.Keys())
+	}
+}